@@ -0,0 +1,493 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"filippo.io/age"
+	"flag"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AnonymizeKeyEnv gives the key of the pseudonyms, the same key gives the same pseudonyms across the scans
+const AnonymizeKeyEnv = "JAVA_DISCOVERY_ANONYMIZE_KEY"
+
+var (
+	// ipv4Pattern is the candidate of the IPv4 address, it is only replaced when parsed as an IP and not a part of a version
+	ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// ipv6Pattern is the candidate of the IPv6 address, at least two colons, it is only replaced when parsed as an IP
+	ipv6Pattern = regexp.MustCompile(`(?i)[0-9a-f:.]*:[0-9a-f.]*:[0-9a-f:.]*`)
+	// hostPatterns capture the hosts of the urls and of the host-like keys, e.g. jdbc:postgresql://db:5432/orders,
+	// jdbc:oracle:thin:@//db:1521/orders, spring.redis.host: cache, SPRING_RABBITMQ_ADDRESSES=mq1:5672,mq2:5672
+	hostPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.\-]*://(?:[^/@\s]*@)?([^/\s?#"'<>;()]+)`),
+		regexp.MustCompile(`(?i)\bjdbc:oracle:thin:[^@\s]*@(?://)?([^/\s?#"'<>;()]+)`),
+		regexp.MustCompile(`(?im)(?:^|[\s-])[\w.\-]*?(?:host|hostname|hosts|address|addresses)\s*[:=]\s*["']?([^\s"'#;]+)`),
+	}
+	// pathPattern is the absolute path of two segments at least in a value, e.g. -Dlogging.file=/home/app/logs/app.log,
+	// the path of a url follows its host, so it is not matched
+	pathPattern     = regexp.MustCompile(`(?:^|[=:,\s"'])(/[\w.\-]+(?:/[\w.\-]*)+)`)
+	hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?)*$`)
+	// userEnvs are the environments revealing the user who runs the app
+	userEnvs = []string{"USER", "LOGNAME", "USERNAME", "SUDO_USER"}
+	// pathEnvs are the environments holding the paths named after the user or the app
+	pathEnvs = []string{"HOME", "PWD", "OLDPWD", "MAIL"}
+	// systemDirs are the dirs of the system paths, which identify nothing, e.g. file:/dev/./urandom
+	systemDirs = []string{"/bin/", "/dev/", "/lib/", "/lib64/", "/proc/", "/sbin/", "/sys/", "/usr/"}
+)
+
+// anonymizationOptions are the flags to pseudonymize the result before it is shared
+type anonymizationOptions struct {
+	enabled     bool
+	keyFile     string
+	mappingFile string
+}
+
+func (a *anonymizationOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&a.enabled, "anonymize", false, "Replace the hostnames, IPs, paths, usernames and app names with the pseudonyms, the versions, dependencies and sizes are kept")
+	fs.StringVar(&a.keyFile, "anonymize-key", "", "File of the key for the pseudonyms, or env "+AnonymizeKeyEnv+", default a random key for this run only")
+	fs.StringVar(&a.mappingFile, "anonymize-mapping", "", "File name to save the pseudonyms with the original values, to de-anonymize later")
+}
+
+// anonymizer creates the anonymizer, nil if not enabled
+func (a *anonymizationOptions) anonymizer(stderr io.Writer) (*Anonymizer, error) {
+	if !a.enabled {
+		if len(a.keyFile) > 0 || len(a.mappingFile) > 0 {
+			return nil, fmt.Errorf("-anonymize-key and -anonymize-mapping require -anonymize")
+		}
+		return nil, nil
+	}
+
+	var key []byte
+	switch {
+	case len(a.keyFile) > 0:
+		b, err := os.ReadFile(a.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = []byte(strings.TrimSpace(string(b)))
+	case len(os.Getenv(AnonymizeKeyEnv)) > 0:
+		key = []byte(os.Getenv(AnonymizeKeyEnv))
+	default:
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "no anonymize key given, the pseudonyms are only consistent within this run\n")
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty anonymize key")
+	}
+	return NewAnonymizer(key, a.mappingFile), nil
+}
+
+// Anonymizer replaces the identifying values with the keyed hash pseudonyms, the same value always gets the same pseudonym
+type Anonymizer struct {
+	key         []byte
+	mappingFile string
+	// encryption encrypts the mapping the same as the result, since it holds the original values
+	encryption *encryptionOptions
+	recipients []age.Recipient
+	// mapping is the pseudonym to the original value
+	mapping map[string]string
+}
+
+func NewAnonymizer(key []byte, mappingFile string) *Anonymizer {
+	return &Anonymizer{key: key, mappingFile: mappingFile, mapping: make(map[string]string)}
+}
+
+// pseudonym is the kind prefixed keyed hash of the value, e.g. host-3f2a9c01d4
+func (a *Anonymizer) pseudonym(kind string, value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	token := kind + "-" + hex.EncodeToString(mac.Sum(nil))[:10]
+	a.mapping[token] = value
+	return token
+}
+
+// path keeps the extension of the file, so the jar or war is still recognized
+func (a *Anonymizer) path(location string) string {
+	if len(location) == 0 {
+		return location
+	}
+	dir, file := path.Split(location)
+	ext := path.Ext(file)
+	var result = a.pseudonym("file", strings.TrimSuffix(file, ext)) + ext
+	if dir = strings.TrimSuffix(dir, "/"); len(dir) > 0 {
+		result = a.dir(dir) + "/" + result
+	}
+	return result
+}
+
+// dir is the pseudonym of the whole directory, the absolute one is still absolute
+func (a *Anonymizer) dir(dir string) string {
+	if strings.HasPrefix(dir, "/") {
+		return "/" + a.pseudonym("dir", dir)
+	}
+	return a.pseudonym("dir", dir)
+}
+
+// scrubber replaces the known identifying values and the IPs in the free text, e.g. the configurations and the jvm options
+type scrubber struct {
+	a        *Anonymizer
+	pattern  *regexp.Regexp
+	replaced map[string]string
+}
+
+func (a *Anonymizer) scrubber(replaced map[string]string) *scrubber {
+	var values []string
+	for value := range replaced {
+		// the short values are too likely to be a part of other words
+		if len(value) >= 3 {
+			values = append(values, value)
+		}
+	}
+	// the longer value wins, e.g. the jar path before its directory
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	var alternatives []string
+	for _, value := range values {
+		// the word boundary only applies next to a word character, e.g. not after the slash of a path
+		alternative := regexp.QuoteMeta(value)
+		if isWordChar(value[0]) {
+			alternative = `\b` + alternative
+		}
+		if isWordChar(value[len(value)-1]) {
+			alternative = alternative + `\b`
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	var s = &scrubber{a: a, replaced: replaced}
+	if len(alternatives) > 0 {
+		s.pattern = regexp.MustCompile(strings.Join(alternatives, "|"))
+	}
+	return s
+}
+
+func (s *scrubber) text(text string) string {
+	text = replaceHosts(text, func(host string) string {
+		return s.a.pseudonym("host", host)
+	})
+	if s.pattern != nil {
+		text = s.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return s.replaced[match]
+		})
+	}
+	text = ipv6Pattern.ReplaceAllStringFunc(text, func(candidate string) string {
+		if ip := net.ParseIP(candidate); ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+			return candidate
+		}
+		return s.a.pseudonym("ip", candidate)
+	})
+	return replaceIPv4(text, func(ip string) string {
+		return s.a.pseudonym("ip", ip)
+	})
+}
+
+// value scrubs a value of the app as text, and replaces the absolute paths in it as well, the known ones or not
+func (s *scrubber) value(value string) string {
+	var b strings.Builder
+	var last int
+	for _, match := range pathPattern.FindAllStringSubmatchIndex(value, -1) {
+		location := value[match[2]:match[3]]
+		if isSystemPath(location) {
+			continue
+		}
+		b.WriteString(value[last:match[2]])
+		if pseudonym, ok := s.replaced[location]; ok {
+			b.WriteString(pseudonym)
+		} else {
+			b.WriteString(s.a.path(location))
+		}
+		last = match[3]
+	}
+	b.WriteString(value[last:])
+	return s.text(b.String())
+}
+
+func isSystemPath(location string) bool {
+	for _, dir := range systemDirs {
+		if strings.HasPrefix(location, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceIPv4 replaces the IPv4 addresses, not the versions of four parts in a name or followed by more, e.g. guava-1.2.3.4.jar
+func replaceIPv4(text string, replace func(ip string) string) string {
+	var b strings.Builder
+	var last int
+	for _, match := range ipv4Pattern.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+		if net.ParseIP(text[start:end]) == nil ||
+			(start > 0 && strings.ContainsRune(".-", rune(text[start-1]))) ||
+			(end < len(text) && text[end] == '-') ||
+			(end+1 < len(text) && text[end] == '.' && isWordChar(text[end+1])) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(replace(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// replaceHosts replaces the hostnames found by the hostPatterns, the ports are kept, the IPs are left to the IP patterns
+func replaceHosts(text string, replace func(host string) string) string {
+	for _, pattern := range hostPatterns {
+		var b strings.Builder
+		var last int
+		for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+			b.WriteString(text[last:match[2]])
+			entries := strings.Split(text[match[2]:match[3]], ",")
+			for i, entry := range entries {
+				// the value of a host-like key can be a url, its host is already replaced
+				if strings.Contains(entry, "/") {
+					continue
+				}
+				if host, _, _ := strings.Cut(entry, ":"); isHostname(host) {
+					entries[i] = replace(host) + strings.TrimPrefix(entry, host)
+				}
+			}
+			b.WriteString(strings.Join(entries, ","))
+			last = match[3]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+// isHostname tells the hostname or FQDN, not the IPs, the localhost or the placeholders like ${REDIS_HOST}
+func isHostname(host string) bool {
+	return hostnamePattern.MatchString(host) &&
+		!ipv4Pattern.MatchString(host) &&
+		!strings.EqualFold(host, "localhost") &&
+		strings.IndexFunc(host, func(r rune) bool { return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') }) >= 0
+}
+
+func (s *scrubber) texts(texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	var result = make([]string, 0, len(texts))
+	for _, t := range texts {
+		result = append(result, s.value(t))
+	}
+	return result
+}
+
+func (s *scrubber) values(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	var result = make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = s.value(v)
+	}
+	return result
+}
+
+// App returns the anonymized copy of the app, the versions, dependencies, sizes and times are kept
+func (a *Anonymizer) App(app *springboot.SpringBootApp) *springboot.SpringBootApp {
+	if a == nil || app == nil {
+		return app
+	}
+	var replaced = make(map[string]string)
+	var replace = func(value string, pseudonym string) string {
+		if len(value) > 0 {
+			replaced[value] = pseudonym
+		}
+		return pseudonym
+	}
+
+	anonymized := *app
+	anonymized.AppName = replace(app.AppName, a.pseudonym("app", app.AppName))
+	if app.Artifact != nil {
+		artifact := *app.Artifact
+		artifact.Group = replace(app.Artifact.Group, a.pseudonym("group", app.Artifact.Group))
+		artifact.Name = replace(app.Artifact.Name, a.pseudonym("app", app.Artifact.Name))
+		anonymized.Artifact = &artifact
+	}
+	anonymized.JarFileLocation = replace(app.JarFileLocation, a.path(app.JarFileLocation))
+	if dir := path.Dir(app.JarFileLocation); len(app.JarFileLocation) > 0 && dir != "/" && dir != "." {
+		replace(dir, a.dir(dir))
+	}
+
+	var runtime *springboot.Runtime
+	if app.Runtime != nil {
+		r := *app.Runtime
+		runtime = &r
+		runtime.Server = replace(app.Runtime.Server, a.pseudonym("host", app.Runtime.Server))
+		for _, env := range app.Runtime.Environments {
+			name, value, found := strings.Cut(env, "=")
+			switch {
+			case !found || len(value) == 0:
+			case contains(userEnvs, name):
+				replace(value, a.pseudonym("user", value))
+			case contains(pathEnvs, name):
+				replace(value, a.dir(value))
+			case name == "HOSTNAME":
+				replace(value, a.pseudonym("host", value))
+			}
+		}
+	}
+
+	// the FQDNs found in the urls and the host-like values are also replaced in the free text, e.g. in a logging configuration,
+	// the single label ones are only replaced where found, since they are too likely to be other words, e.g. eureka
+	for _, text := range appTexts(app) {
+		replaceHosts(text, func(host string) string {
+			if strings.Contains(host, ".") {
+				replace(host, a.pseudonym("host", host))
+			}
+			return host
+		})
+	}
+
+	s := a.scrubber(replaced)
+	anonymized.ApplicationConfigurations = s.values(app.ApplicationConfigurations)
+	anonymized.LoggingConfigurations = s.values(app.LoggingConfigurations)
+	if runtime != nil {
+		runtime.JavaCmd = s.text(runtime.JavaCmd)
+		runtime.JvmOptions = s.texts(runtime.JvmOptions)
+		if runtime.JdkRelease != nil {
+			release := *runtime.JdkRelease
+			release.JavaHome = s.text(release.JavaHome)
+			runtime.JdkRelease = &release
+		}
+		if runtime.JvmOptionSources != nil {
+			sources := make([]springboot.JvmOption, len(runtime.JvmOptionSources))
+			for i, option := range runtime.JvmOptionSources {
+				sources[i] = springboot.JvmOption{Value: s.value(option.Value), Source: option.Source, ArgFile: s.value(option.ArgFile)}
+			}
+			runtime.JvmOptionSources = sources
+		}
+		runtime.Environments = s.texts(runtime.Environments)
+	}
+	anonymized.Runtime = runtime
+	return &anonymized
+}
+
+// appTexts are the free texts of the app, which the scrubber goes through
+func appTexts(app *springboot.SpringBootApp) []string {
+	var texts []string
+	for _, v := range app.ApplicationConfigurations {
+		texts = append(texts, v)
+	}
+	for _, v := range app.LoggingConfigurations {
+		texts = append(texts, v)
+	}
+	if app.Runtime != nil {
+		texts = append(texts, app.Runtime.JvmOptions...)
+		texts = append(texts, app.Runtime.Environments...)
+	}
+	return texts
+}
+
+// Server returns the pseudonym of the server
+func (a *Anonymizer) Server(server string) string {
+	if a == nil {
+		return server
+	}
+	return a.pseudonym("host", server)
+}
+
+// Message scrubs the server and the IPs from the error message
+func (a *Anonymizer) Message(server string, message string) string {
+	if a == nil {
+		return message
+	}
+	return a.scrubber(map[string]string{server: a.pseudonym("host", server)}).text(message)
+}
+
+// ScanResult returns the anonymized copy of the result
+func (a *Anonymizer) ScanResult(result *ScanResult) *ScanResult {
+	if a == nil || result == nil {
+		return result
+	}
+	anonymized := *result
+	anonymized.Hosts = make([]*HostFacts, 0, len(result.Hosts))
+	for _, host := range result.Hosts {
+		h := *host
+		h.Server = a.Server(host.Server)
+		anonymized.Hosts = append(anonymized.Hosts, &h)
+	}
+	anonymized.Apps = make([]*springboot.SpringBootApp, 0, len(result.Apps))
+	for _, app := range result.Apps {
+		anonymized.Apps = append(anonymized.Apps, a.App(app))
+	}
+	anonymized.Errors = make([]*ScanError, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		anonymized.Errors = append(anonymized.Errors, &ScanError{Server: a.Server(e.Server), Message: a.Message(e.Server, e.Message)})
+	}
+	return &anonymized
+}
+
+// StreamRecord returns the anonymized copy of the ndjson record
+func (a *Anonymizer) StreamRecord(record *StreamRecord) *StreamRecord {
+	if a == nil || record == nil {
+		return record
+	}
+	return &StreamRecord{
+		Type:    record.Type,
+		Server:  a.Server(record.Server),
+		App:     a.App(record.App),
+		Message: a.Message(record.Server, record.Message),
+	}
+}
+
+// SaveMapping writes the pseudonyms with the original values, if the mapping file is given, encrypted when the result is
+func (a *Anonymizer) SaveMapping() error {
+	if a == nil || len(a.mappingFile) == 0 {
+		return nil
+	}
+	b, err := json.MarshalIndent(a.mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	output, err := NewOutput(a.mappingFile, "json")
+	if err != nil {
+		return err
+	}
+	if err = a.encryption.wrap(output, a.recipients); err != nil {
+		output.Close()
+		return err
+	}
+	if _, err = output.writer.Write(append(b, '\n')); err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
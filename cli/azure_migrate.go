@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/Azure/discover-java-apps/springboot"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AzureMigrateApp is a row of the azure-migrate csv, the columns marked with * are mandatory.
+// The columns of the server, *Server name, *Memory (In MB), *OS name and OS version, are named as in the csv template of
+// the import-based server discovery of Azure Migrate, the columns of the web app follow no published template
+type AzureMigrateApp struct {
+	ServerName       string `csv:"*Server name"`
+	AppName          string `csv:"*Web app name"`
+	AppType          string `csv:"*Web app type"`
+	Framework        string `csv:"Framework"`
+	FrameworkVersion string `csv:"Framework version"`
+	Runtime          string `csv:"Runtime"`
+	RuntimeVersion   string `csv:"Runtime version"`
+	BuildJdkVersion  string `csv:"Build runtime version"`
+	Memory           int64  `csv:"*Memory (In MB)"`
+	Ports            string `csv:"Ports"`
+	OsName           string `csv:"*OS name"`
+	OsVersion        string `csv:"OS version"`
+	ArtifactGroup    string `csv:"Package group"`
+	ArtifactName     string `csv:"Package name"`
+	ArtifactVersion  string `csv:"Package version"`
+	Location         string `csv:"Package location"`
+	Size             int64  `csv:"Package size (In MB)"`
+	LastModifiedTime string `csv:"Last modified time"`
+	AppId            string `csv:"Web app ID"`
+}
+
+type azureMigrateConverter struct {
+}
+
+// NewAzureMigrateConverter maps the apps onto the Azure Migrate web app import template
+func NewAzureMigrateConverter() Converter[[]*springboot.SpringBootApp, []*AzureMigrateApp] {
+	return &azureMigrateConverter{}
+}
+
+func (a azureMigrateConverter) Convert(apps []*springboot.SpringBootApp) []*AzureMigrateApp {
+	var results []*AzureMigrateApp
+
+	for _, app := range apps {
+		if app == nil {
+			continue
+		}
+
+		var row = &AzureMigrateApp{
+			AppName:         app.AppName,
+			AppType:         "Java",
+			Runtime:         "Java",
+			BuildJdkVersion: app.BuildJdkVersion,
+			Location:        app.JarFileLocation,
+			Size:            ceilMiB(app.JarSize),
+			AppId:           springboot.AppId(app),
+		}
+		if springboot.SpringBootAppTypes.Contains(app.AppType) {
+			row.Framework = "Spring Boot"
+			row.FrameworkVersion = app.SpringBootVersion
+		}
+		if !app.LastModifiedTime.IsZero() {
+			row.LastModifiedTime = app.LastModifiedTime.UTC().Format(time.RFC3339)
+		}
+		if app.Artifact != nil {
+			row.ArtifactGroup = app.Artifact.Group
+			row.ArtifactName = app.Artifact.Name
+			row.ArtifactVersion = app.Artifact.Version
+		}
+		if app.Runtime != nil {
+			row.ServerName = app.Runtime.Server
+			row.RuntimeVersion = app.Runtime.RuntimeJdkVersion
+			row.Memory = ceilMiB(app.Runtime.JvmMemory)
+			row.Ports = joinAppPorts(app.Runtime)
+			row.OsName = app.Runtime.OsName
+			row.OsVersion = app.Runtime.OsVersion
+		}
+		results = append(results, row)
+	}
+	return results
+}
+
+// ceilMiB rounds the bytes up to MiB, so a small heap or jar is not reported as 0
+func ceilMiB(size int64) int64 {
+	return (size + springboot.MiB - 1) / springboot.MiB
+}
+
+// joinAppPorts lists the app port first, then the other binding ports, separated by semicolon as the template expects
+func joinAppPorts(runtime *springboot.Runtime) string {
+	var ports []string
+	if runtime.AppPort > 0 {
+		ports = append(ports, strconv.Itoa(runtime.AppPort))
+	}
+	var others = append([]int(nil), runtime.BindingPorts...)
+	sort.Ints(others)
+	for _, port := range others {
+		if port != runtime.AppPort {
+			ports = append(ports, strconv.Itoa(port))
+		}
+	}
+	return strings.Join(ports, ";")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"github.com/Azure/discover-java-apps/springboot"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Azure migrate output", func() {
+	It("should write the csv as the golden file", func() {
+		result := newTestScanResult()
+		small := *result.Apps[0]
+		small.AppName = "tiny"
+		small.JarFileLocation = "/opt/tiny.jar"
+		small.JarSize = 1
+		small.AppType = springboot.ExecutableJar
+		runtime := *small.Runtime
+		runtime.JvmMemory = 512 * springboot.KiB
+		runtime.AppPort = 0
+		runtime.BindingPorts = nil
+		small.Runtime = &runtime
+		result.Apps = append(result.Apps, &small)
+
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: "azure-migrate"}
+		Expect(output.Write(NewAzureMigrateConverter().Convert(result.Apps))).Should(Succeed())
+
+		golden, err := os.ReadFile(filepath.Join("testdata", "azure-migrate.csv"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(b.String()).Should(Equal(string(golden)))
+	})
+
+	It("should round the sizes up to MB", func() {
+		Expect(ceilMiB(0)).Should(Equal(int64(0)))
+		Expect(ceilMiB(1)).Should(Equal(int64(1)))
+		Expect(ceilMiB(springboot.MiB)).Should(Equal(int64(1)))
+		Expect(ceilMiB(springboot.MiB + 1)).Should(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSeparator = ";"
+
+var indexPattern = regexp.MustCompile(`^(.*)\[(\d+)]$`)
+
+// columns are the json paths selected from the full app model for the csv output, e.g. runtime.bindingPorts
+type columns []string
+
+func (c *columns) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *columns) Set(value string) error {
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); len(column) > 0 {
+			*c = append(*c, column)
+		}
+	}
+	return nil
+}
+
+// validate checks every column is a json path of the full app model, the map keys and list indexes are free
+func (c columns) validate() error {
+	var unknown []string
+	for _, column := range c {
+		if !validPath(reflect.TypeOf(springboot.SpringBootApp{}), strings.Split(column, ".")) {
+			unknown = append(unknown, column)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown columns %s, valid columns: %s", strings.Join(unknown, ", "), strings.Join(modelPaths(reflect.TypeOf(springboot.SpringBootApp{}), ""), ", "))
+	}
+	return nil
+}
+
+// validPath walks the json names of the type by the path segments
+func validPath(t reflect.Type, segments []string) bool {
+	for i, segment := range segments {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		var indexed bool
+		if match := indexPattern.FindStringSubmatch(segment); match != nil {
+			segment, indexed = match[1], true
+		}
+		switch {
+		case t.Kind() == reflect.Map:
+			// the rest is the map key, which may contain dots, e.g. applicationConfigurations.application.yml
+			return !indexed
+		case t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}):
+			field, ok := jsonField(t, segment)
+			if !ok {
+				return false
+			}
+			t = field.Type
+		default:
+			return false
+		}
+		if indexed {
+			if t.Kind() != reflect.Slice {
+				return false
+			}
+			t = t.Elem()
+		}
+		if i == len(segments)-1 {
+			return true
+		}
+	}
+	return len(segments) == 0
+}
+
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && tag != "-" && (tag == name || len(tag) == 0 && field.Name == name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// modelPaths lists the json paths of the type, <key> for a map key and [n] for a list index
+func modelPaths(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Struct && t.Elem().Kind() != reflect.Pointer {
+			return []string{prefix}
+		}
+		if t.Kind() == reflect.Slice {
+			prefix += "[n]"
+		}
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Map:
+		return []string{prefix, prefix + ".<key>"}
+	case t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}):
+		var paths []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if len(name) == 0 {
+				name = field.Name
+			}
+			if len(prefix) > 0 {
+				name = prefix + "." + name
+			}
+			paths = append(paths, modelPaths(field.Type, name)...)
+		}
+		return paths
+	default:
+		return []string{prefix}
+	}
+}
+
+// writeColumns writes the selected columns of the apps, the slices and maps are flattened with the separator
+func (o *Output) writeColumns(records any, writer io.Writer) error {
+	result, ok := records.(*ScanResult)
+	if !ok {
+		return fmt.Errorf("columns are not supported for %T", records)
+	}
+	var separator = o.separator
+	if len(separator) == 0 {
+		separator = defaultSeparator
+	}
+
+	var csvWriter = csv.NewWriter(writer)
+	defer csvWriter.Flush()
+	if err := csvWriter.Write(o.columns); err != nil {
+		return err
+	}
+
+	for _, app := range result.Apps {
+		if app == nil {
+			continue
+		}
+		doc, err := toGeneric(app)
+		if err != nil {
+			return err
+		}
+		var row []string
+		for _, column := range o.columns {
+			row = append(row, flatten(lookupPath(doc, column), separator))
+		}
+		if err = csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	return csvWriter.Error()
+}
+
+// toGeneric turns the value into the generic json model, so the json path follows the json names
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var generic any
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// lookupPath walks the dot separated path, a map key containing dots is matched greedily, e.g. applicationConfigurations.application.yml
+func lookupPath(doc any, path string) any {
+	segments := strings.Split(path, ".")
+	current := doc
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		var index = -1
+		if match := indexPattern.FindStringSubmatch(segment); match != nil {
+			segment = match[1]
+			index, _ = strconv.Atoi(match[2])
+		}
+
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value, found := m[segment]
+		for j := i + 1; !found && j < len(segments); j++ {
+			segment = strings.Join(segments[i:j+1], ".")
+			if value, found = m[segment]; found {
+				i = j
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		if index >= 0 {
+			list, ok := value.([]any)
+			if !ok || index >= len(list) {
+				return nil
+			}
+			value = list[index]
+		}
+		current = value
+	}
+	return current
+}
+
+func flatten(value any, separator string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		var items []string
+		for _, item := range v {
+			items = append(items, flatten(item, separator))
+		}
+		return strings.Join(items, separator)
+	case map[string]any:
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var items []string
+		for _, k := range keys {
+			items = append(items, k+"="+flatten(v[k], separator))
+		}
+		return strings.Join(items, separator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
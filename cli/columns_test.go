@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"io"
+	"strings"
+)
+
+var _ = Describe("Columns", func() {
+	DescribeTable("should look up the json path of the app",
+		func(path string, expected string) {
+			doc, err := toGeneric(newTestScanResult().Apps[0])
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(columns{path}.validate()).Should(Succeed())
+			Expect(flatten(lookupPath(doc, path), ";")).Should(Equal(expected))
+		},
+		Entry("top level", "appName", "hellospring"),
+		Entry("nested", "runtime.server", "10.0.0.4"),
+		Entry("nested struct", "artifact.group", "com.example"),
+		Entry("list", "runtime.bindingPorts", "8080;8081"),
+		Entry("indexed", "dependencies[1]", "postgresql-42.5.0.jar"),
+		Entry("index out of range", "dependencies[5]", ""),
+		Entry("map key with dots", "applicationConfigurations.application.yml", "spring.datasource.url: jdbc:postgresql://db-prod-01.corp.local:5432/orders"),
+		Entry("absent map key", "loggingConfigurations.logback.xml", ""),
+		Entry("absent pointer", "runtime.jdkRelease.vendor", ""),
+		Entry("indexed struct", "runtime.jvmOptionSources[0].source", ""),
+	)
+
+	DescribeTable("should reject the unknown paths",
+		func(path string) {
+			err := columns{"appName", path}.validate()
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring(path))
+			Expect(err.Error()).Should(ContainSubstring("runtime.server"))
+		},
+		Entry("misspelled", "runtime.srever"),
+		Entry("below a value", "appName.length"),
+		Entry("index of a value", "appName[0]"),
+		Entry("field of a time", "lastModifiedTime.year"),
+	)
+
+	It("should write the selected columns", func() {
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: "csv", columns: columns{"appName", "runtime.bindingPorts", "dependencies[0]"}, separator: "|"}
+		Expect(output.Write(newTestScanResult())).Should(Succeed())
+		records, err := csv.NewReader(&b).ReadAll()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(records).Should(Equal([][]string{{"appName", "runtime.bindingPorts", "dependencies[0]"}, {"hellospring", "8080|8081", "spring-boot-2.7.5.jar"}}))
+	})
+
+	It("should reject the columns for other formats", func() {
+		g := globalOptions{format: "azure-migrate", columns: columns{"appName"}}
+		_, err := g.output(io.Discard, io.Discard)
+		Expect(err).Should(HaveOccurred())
+		Expect(strings.Contains(err.Error(), "-format csv")).Should(BeTrue())
+	})
+})
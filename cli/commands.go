@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// builtinAllowlist allows the commands of the discovery only, noAllowlist allows any command
+	builtinAllowlist = "builtin"
+	noAllowlist      = "none"
+)
+
+// commandOptions are the flags to review, restrict and audit the commands run on the servers
+type commandOptions struct {
+	dryRun    bool
+	allowlist string
+	auditFile string
+}
+
+func (c *commandOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&c.dryRun, "dry-run", false, "Print the commands which would be run on every server, without connecting to any")
+	fs.StringVar(&c.allowlist, "command-allowlist", builtinAllowlist, "Commands allowed to run on the servers, builtin is the commands of the discovery, none allows any, or a file of the command names or templates one per line")
+	fs.StringVar(&c.auditFile, "audit-file", "", "File name to append the audit of the commands run, each with the host, timestamp, exit status, duration and output size")
+}
+
+// commandAllowlist reads the allowlist, nil if any command is allowed
+func (c *commandOptions) commandAllowlist() (*springboot.CommandAllowlist, error) {
+	switch c.allowlist {
+	case builtinAllowlist:
+		return springboot.DefaultCommandAllowlist(), nil
+	case noAllowlist, "":
+		return nil, nil
+	}
+	f, err := os.Open(c.allowlist)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return springboot.ReadCommandAllowlist(f)
+}
+
+// openAudit opens the audit file to append, nil if not set
+func (c *commandOptions) openAudit() (*springboot.Audit, io.Closer, error) {
+	if len(c.auditFile) == 0 {
+		return nil, nil, nil
+	}
+	f, err := os.OpenFile(c.auditFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return springboot.NewAudit(f), f, nil
+}
+
+// printDryRun prints the commands per server, with how the server is reached and how the denied commands are escalated
+func printDryRun(w io.Writer, infos []springboot.ServerConnectionInfo, username string, escalation *springboot.Escalation,
+	allowlist *springboot.CommandAllowlist, jumpHosts springboot.JumpHostResolver, proxy *springboot.Proxy) {
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s@%s:%d\n", username, info.Server, info.Port)
+		// the proxy is used for the first hop
+		firstHop := info.Server
+		if jumpHosts != nil {
+			var hops []string
+			for _, jumpHost := range jumpHosts(info.Server, info.Port) {
+				port := jumpHost.Port
+				if port == 0 {
+					port = springboot.DefaultSshPort
+				}
+				hops = append(hops, fmt.Sprintf("%s:%d", jumpHost.Server, port))
+			}
+			if len(hops) > 0 {
+				fmt.Fprintf(w, "# through the jump hosts %s\n", strings.Join(hops, ","))
+				firstHop = jumpHosts(info.Server, info.Port)[0].Server
+			}
+		}
+		if proxy != nil && !proxy.Bypass(firstHop) {
+			fmt.Fprintf(w, "# through the proxy %s\n", proxy)
+		}
+		if escalation == nil || escalation.Method == springboot.EscalationNone {
+			fmt.Fprintln(w, "# the commands denied to the user are not escalated")
+		} else {
+			fmt.Fprintf(w, "# the commands denied to the user are run again by: %s\n", escalation.Command("<command>"))
+		}
+
+		for _, command := range springboot.LinuxCommands {
+			rendered := springboot.RenderCommand(command)
+			if allowlist != nil && !springboot.Contains(allowlist.Templates(), command.Template) {
+				rendered += "  # not on the allowlist, rejected"
+			}
+			fmt.Fprintf(w, "%s: %s\n", command.Name, rendered)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"os"
+	"strings"
+)
+
+const configUsage = `Usage: discovery config <command> [flags] [file...]
+
+Commands:
+  validate <file>  Report every invalid regex and yamlpath in the config file
+  dump [file...]   Print the effective config, with the files overlaid on the defaults in order
+  schema           Print the JSON Schema of the config file
+`
+
+func runConfigCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var g globalOptions
+	fs := newFlagSet("config", configUsage, stderr)
+	g.register(fs, "yaml")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	output, err := g.output(stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	defer output.Close()
+
+	switch positional[0] {
+	case "validate":
+		if len(positional) != 2 {
+			fs.Usage()
+			return 2
+		}
+		return validateConfig(positional[1], output.writer)
+	case "dump":
+		return dumpConfig(append(g.cfgFiles, positional[1:]...), output.writer, stderr)
+	case "schema":
+		fmt.Fprint(output.writer, springboot.ConfigSchema)
+		return 0
+	default:
+		fs.Usage()
+		return 2
+	}
+}
+
+func validateConfig(filename string, stdout io.Writer) int {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(stdout, "%s: %s\n", filename, err.Error())
+		return 1
+	}
+
+	errs := springboot.ValidateConfig(b)
+	if len(errs) == 0 {
+		fmt.Fprintf(stdout, "%s: ok\n", filename)
+		return 0
+	}
+
+	for _, e := range errs {
+		if e.Line > 0 {
+			fmt.Fprintf(stdout, "%s:%d:%d: %s %q: %s\n", filename, e.Line, e.Column, e.Path, e.Value, errors.Unwrap(e))
+		} else {
+			fmt.Fprintf(stdout, "%s: %s\n", filename, e.Error())
+		}
+	}
+	fmt.Fprintf(stdout, "%d invalid config entries found\n", len(errs))
+	return 1
+}
+
+func dumpConfig(files []string, stdout io.Writer, stderr io.Writer) int {
+	cfg, warnings, err := springboot.LoadConfig(files...)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(stderr, "warning: %s=%s: %s\n", warning.Path, warning.Value, errors.Unwrap(warning))
+	}
+
+	out, err := springboot.DumpConfig(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	fmt.Fprint(stdout, out)
+	return 0
+}
+
+// configFiles collects the repeatable -config flag
+type configFiles []string
+
+func (c *configFiles) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *configFiles) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// useConfigFiles overlays the config files on top of the defaults and makes them effective for discovery
+func useConfigFiles(files configFiles) error {
+	if len(files) == 0 {
+		return nil
+	}
+	cfg, warnings, err := springboot.LoadConfig(files...)
+	if err != nil {
+		return err
+	}
+	springboot.UseConfig(cfg, warnings)
+	return nil
+}
@@ -2,21 +2,24 @@ package main
 
 import "github.com/Azure/discover-java-apps/springboot"
 
-func NewUsernamePasswordCredentialProvider(username, password string) springboot.CredentialProvider {
-	return &usernamePasswordCredentialProvider{Username: username, Password: password}
+// NewUsernamePasswordCredentialProvider gives the credential, the escalation is optional and nil is passwordless sudo
+func NewUsernamePasswordCredentialProvider(username, password string, escalation *springboot.Escalation) springboot.CredentialProvider {
+	return &usernamePasswordCredentialProvider{Username: username, Password: password, Escalation: escalation}
 }
 
 type usernamePasswordCredentialProvider struct {
-	Username string
-	Password string
+	Username   string
+	Password   string
+	Escalation *springboot.Escalation
 }
 
 func (p usernamePasswordCredentialProvider) GetCredentials() ([]*springboot.Credential, error) {
 	return []*springboot.Credential{
 		{
-			Id:       p.Username,
-			Username: p.Username,
-			Password: p.Password,
+			Id:         p.Username,
+			Username:   p.Username,
+			Password:   p.Password,
+			Escalation: p.Escalation,
 		},
 	}, nil
 }
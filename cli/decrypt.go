@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const decryptUsage = `Usage: discovery decrypt [flags] <encrypted file>
+
+Decrypt a result encrypted by -recipient or -passphrase, either binary or armored.
+The identity files are the age secret keys, e.g. generated by age-keygen, without them the passphrase is used.
+`
+
+func runDecryptCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var identityFiles configFiles
+	var filename string
+	fs := newFlagSet("decrypt", decryptUsage, stderr)
+	fs.Var(&identityFiles, "identity", "File of the age secret keys, can be repeated")
+	fs.StringVar(&filename, "file", "", "File name for decrypted result, default console")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	content, err := os.ReadFile(positional[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	identities, err := readIdentities(identityFiles, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	plain, err := decrypt(content, identities...)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot decrypt %s, %s\n", positional[0], err.Error())
+		return 1
+	}
+
+	output, err := NewOutput(filename, "")
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if _, err = io.Copy(output.writer, plain); err != nil {
+		output.Close()
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if err = output.Close(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// readIdentities reads the secret keys from the files, or the passphrase when no file is given
+func readIdentities(files []string, stderr io.Writer) ([]age.Identity, error) {
+	if len(files) == 0 {
+		passphrase, err := readPassphrase(stderr, false)
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	var identities []age.Identity
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identities from %s, %w", file, err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
+
+// ageHeader is the first line of the binary age file
+const ageHeader = "age-encryption.org/v1"
+
+// isEncrypted tells the age encrypted content, either binary or armored
+func isEncrypted(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	return bytes.HasPrefix(trimmed, []byte(ageHeader)) || bytes.HasPrefix(trimmed, []byte(armor.Header))
+}
+
+// decryptionOptions are the flags to decrypt the results read by report and diff, the identities are read on the first encrypted result
+type decryptionOptions struct {
+	identityFiles configFiles
+	stderr        io.Writer
+	identities    []age.Identity
+}
+
+func (d *decryptionOptions) register(fs *flag.FlagSet, stderr io.Writer) {
+	d.stderr = stderr
+	fs.Var(&d.identityFiles, "identity", "File of the age secret keys to read an encrypted result, can be repeated, without it the passphrase is used")
+}
+
+// decrypt returns the content as is when it is not encrypted
+func (d *decryptionOptions) decrypt(content []byte) ([]byte, error) {
+	if !isEncrypted(content) {
+		return content, nil
+	}
+	if d == nil {
+		return nil, errors.New("the result is encrypted")
+	}
+	if d.identities == nil {
+		identities, err := readIdentities(d.identityFiles, d.stderr)
+		if err != nil {
+			return nil, err
+		}
+		d.identities = identities
+	}
+	plain, err := decrypt(content, d.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(plain)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"strings"
+)
+
+const diffUsage = `Usage: discovery diff [flags] <old.json> <new.json>
+
+Compare two results saved by the discover command, apps are matched by server and jar file location.
+Added and removed apps, version bumps, dependency, configuration and runtime changes are reported.
+`
+
+func runDiffCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var g globalOptions
+	fs := newFlagSet("diff", diffUsage, stderr)
+	g.register(fs, "text", "json")
+	var decryption decryptionOptions
+	decryption.register(fs, stderr)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err = g.setup(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	before, err := loadScan(positional[0], &decryption)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	after, err := loadScan(positional[1], &decryption)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	diff := springboot.DiffScans(before.Apps, after.Apps)
+
+	output, err := g.output(stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	defer output.Close()
+
+	if strings.EqualFold(g.format, "text") {
+		_, err = io.WriteString(output.writer, diff.Summary())
+	} else {
+		err = output.Write(diff)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
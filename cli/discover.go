@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"github.com/docker/go-units"
+	"io"
+	"strings"
+	"time"
+)
+
+const discoverUsage = `Usage: discovery discover -server <servers> -username <username> -password <password> [flags]
+
+Discover java apps from the servers over ssh
+`
+
+func runDiscoverCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var g globalOptions
+	var servers string
+	var port int
+	var username string
+	var password string
+	var stateDir string
+	var resume bool
+	var jarCacheDir string
+	var jarRead string
+	var bandwidthLimit string
+	var jump jumpOptions
+	var proxy proxyOptions
+	var escalation escalationOptions
+	var commands commandOptions
+
+	fs := newFlagSet("discover", discoverUsage, stderr)
+	g.register(fs, supportedFormats...)
+	g.encryption.register(fs)
+	g.anonymization.register(fs)
+	jump.register(fs)
+	proxy.register(fs)
+	escalation.register(fs)
+	commands.register(fs)
+	fs.StringVar(&servers, "server", "", "Target servers to be discovered, separated by comma")
+	fs.StringVar(&username, "username", "", "Username for ssh login")
+	fs.StringVar(&password, "password", "", "Password for ssh login")
+	fs.IntVar(&port, "port", 22, "The ssh port, default 22")
+	fs.StringVar(&stateDir, "state-dir", "", "Directory to checkpoint the finished hosts, so the interrupted scan can be resumed")
+	fs.StringVar(&jarCacheDir, "jar-cache-dir", "", "Directory to cache the jar facts by checksum, so the jar deployed on many servers is read once across the runs")
+	fs.StringVar(&jarRead, "jar-read", string(springboot.JarReadAuto), "How the jar is read, unzip pipes only the needed entries on the server, sftp reads the zip with range reads, auto uses unzip when it exists")
+	fs.StringVar(&bandwidthLimit, "bandwidth-limit", "", "Bytes per second to read the jars from a server, e.g. 512KiB, default unlimited")
+	fs.BoolVar(&resume, "resume", false, "Resume the scan checkpointed in -state-dir, the finished hosts are skipped and the failed ones retried")
+	if _, err := parseFlags(fs, args); err != nil {
+		return exitCode(err)
+	}
+
+	var infos []springboot.ServerConnectionInfo
+	for _, server := range strings.Split(servers, ",") {
+		if server = strings.TrimSpace(server); len(server) > 0 {
+			infos = append(infos, springboot.ServerConnectionInfo{Server: server, Port: port})
+		}
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(stderr, "no server specified")
+		fs.Usage()
+		return 2
+	}
+
+	ctx, err := g.setup()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	azureLogger := springboot.GetAzureLogger(ctx, map[string]string{
+		"server": servers,
+	})
+
+	state, err := OpenScanState(stateDir, resume, &g)
+	if err != nil {
+		azureLogger.Error(err, "error when opening state dir", "dir", stateDir)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	var sshOpts []springboot.SshOption
+	if !commands.dryRun {
+		if err = jump.readPassword(stderr); err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return 1
+		}
+		if err = escalation.readPassword(stderr); err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return 1
+		}
+	}
+	jumpOpt, err := jump.sshOption()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	if jumpOpt != nil {
+		sshOpts = append(sshOpts, jumpOpt)
+	}
+	proxyOpt, err := proxy.sshOption()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	if proxyOpt != nil {
+		sshOpts = append(sshOpts, proxyOpt)
+	}
+
+	credEscalation, err := escalation.escalation()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	allowlist, err := commands.commandAllowlist()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	if allowlist != nil {
+		sshOpts = append(sshOpts, springboot.WithCommandAllowlist(allowlist))
+	}
+	if commands.dryRun {
+		jumpHosts, _ := jump.resolver()
+		proxyServer, _ := proxy.proxy()
+		printDryRun(stdout, infos, username, credEscalation, allowlist, jumpHosts, proxyServer)
+		return 0
+	}
+
+	var opts []springboot.DiscoveryOption
+	strategy := springboot.JarReadStrategy(strings.ToLower(jarRead))
+	if !springboot.Contains(springboot.JarReadStrategies, strategy) {
+		fmt.Fprintf(stderr, "unsupported jar read %s, supported: %s, %s, %s\n", jarRead, springboot.JarReadAuto, springboot.JarReadSftp, springboot.JarReadUnzip)
+		return 2
+	}
+	opts = append(opts, springboot.WithJarReadStrategy(strategy))
+	if len(bandwidthLimit) > 0 {
+		limit, err := units.RAMInBytes(bandwidthLimit)
+		if err != nil || limit <= 0 {
+			fmt.Fprintf(stderr, "invalid bandwidth limit %s, e.g. 512KiB or 2MiB\n", bandwidthLimit)
+			return 2
+		}
+		opts = append(opts, springboot.WithBandwidthLimit(limit))
+	}
+	if len(jarCacheDir) > 0 {
+		cache, err := springboot.NewFileJarCache(jarCacheDir)
+		if err != nil {
+			azureLogger.Error(err, "error when opening jar cache dir", "dir", jarCacheDir)
+			fmt.Fprintln(stderr, err.Error())
+			return 1
+		}
+		opts = append(opts, springboot.WithJarCache(cache))
+	}
+
+	audit, auditFile, err := commands.openAudit()
+	if err != nil {
+		azureLogger.Error(err, "error when opening audit file", "filename", commands.auditFile)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if audit != nil {
+		defer auditFile.Close()
+		sshOpts = append(sshOpts, springboot.WithAudit(audit))
+	}
+
+	output, err := g.output(stdout, stderr)
+	if err != nil {
+		azureLogger.Error(err, "error when creating output", "filename", g.filename)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	rc := DoSpringBootDiscovery(ctx, infos, NewUsernamePasswordCredentialProvider(username, password, credEscalation), sshOpts, output, stderr, state, opts...)
+	// the encrypted output is finished when closed, so the error matters
+	if err = output.Close(); err != nil {
+		azureLogger.Error(err, "error when closing output", "filename", g.filename)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if err = output.anonymizer.SaveMapping(); err != nil {
+		azureLogger.Error(err, "error when saving anonymize mapping", "filename", g.anonymization.mappingFile)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if err = audit.Err(); err != nil {
+		azureLogger.Error(err, "error when writing audit file", "filename", commands.auditFile)
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return rc
+}
+
+// DoSpringBootDiscovery discovers the servers in order, the state is optional to checkpoint or resume the scan,
+// the ssh options are added to the defaults, e.g. the jump hosts
+func DoSpringBootDiscovery(ctx context.Context, infos []springboot.ServerConnectionInfo, credentialProvider springboot.CredentialProvider, sshOpts []springboot.SshOption, output *Output, stderr io.Writer, state *ScanState, opts ...springboot.DiscoveryOption) int {
+	azureLogger := springboot.GetAzureLogger(ctx)
+	var executor = springboot.NewSpringBootDiscoveryExecutor(
+		credentialProvider,
+		springboot.DefaultServerConnectorFactory(append([]springboot.SshOption{
+			springboot.WithConnectionTimeout(time.Duration(5) * time.Second),
+			springboot.WithHostKeyCallback(MemoryHostKeyCallbackFunction()),
+			springboot.WithKeepAlive(time.Duration(30) * time.Second),
+		}, sshOpts...)...),
+		springboot.YamlCfg,
+		opts...,
+	)
+
+	var result = NewScanResult()
+	if state != nil {
+		result.ScanTime = state.ScanTime()
+	}
+	var streamFailed bool
+	// write is a no-op unless the output is streamed, the first write error is reported and the discovery goes on
+	write := func(record *StreamRecord) {
+		if !output.stream() || streamFailed {
+			return
+		}
+		if err := output.Write(output.anonymizer.StreamRecord(record)); err != nil {
+			azureLogger.Error(err, "error when write to target file")
+			fmt.Fprintf(stderr, "Error occurred while writing to file, please check discovery.log, %s\n", issueHint)
+			streamFailed = true
+		}
+	}
+
+	for _, info := range infos {
+		if finished := state.Finished(info); finished != nil {
+			azureLogger.Info("skip the host finished before", "host", info.Server)
+			for _, app := range finished.Apps {
+				write(newStreamRecord(info.Server, app, nil))
+			}
+			result.Hosts = append(result.Hosts, finished.Host)
+			result.Apps = append(result.Apps, finished.Apps...)
+			continue
+		}
+
+		start := time.Now()
+		var discovered []*springboot.SpringBootApp
+		var errs []error
+		err := executor.DiscoverStream(ctx, func(app *springboot.SpringBootApp, err error) {
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				discovered = append(discovered, app)
+			}
+			write(newStreamRecord(info.Server, app, err))
+		}, info)
+		if err != nil {
+			write(newStreamRecord(info.Server, nil, err))
+		} else {
+			err = springboot.Join(errs...)
+		}
+
+		if err != nil {
+			azureLogger.Error(err, "failed to discover", "host", info.Server)
+			fmt.Fprintf(stderr, "Error occurred during discovery of %s, please check discovery.log, %s\n", info.Server, issueHint)
+		}
+		if len(discovered) == 0 && err == nil {
+			fmt.Fprintln(stderr, "no app discovered from "+info.Server)
+		}
+		host := &HostState{Host: result.AddHost(info, start, discovered, err), Apps: discovered}
+		if err != nil {
+			host.Errors = result.Errors[len(result.Errors)-1:]
+		}
+		if err = state.Save(info, host); err != nil {
+			azureLogger.Error(err, "error when saving the state of host", "host", info.Server)
+			fmt.Fprintf(stderr, "Error occurred while saving the state of %s, the scan cannot be resumed from it, %s\n", info.Server, err.Error())
+		}
+	}
+
+	if output.stream() {
+		if streamFailed || len(result.Errors) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	var failed = len(result.Errors) > 0
+	// nothing is printed for the empty json as before, the other formats are always written,
+	// e.g. the csv header, or the documents with the hosts and errors
+	if len(result.Apps) == 0 && strings.EqualFold(strings.TrimSpace(output.format), "json") {
+		if failed {
+			return 1
+		}
+		return 0
+	}
+
+	if err := writeScan(output, result); err != nil {
+		azureLogger.Error(err, "error when write to target file")
+		fmt.Fprintf(stderr, "Error occurred while writing to file, please check discovery.log, %s\n", issueHint)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
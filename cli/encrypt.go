@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"flag"
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+// PassphraseEnv gives the passphrase without prompting, e.g. in the scheduled scans, it is not DISCOVERY_ prefixed since those are the config overrides
+const PassphraseEnv = "JAVA_DISCOVERY_PASSPHRASE"
+
+// recipients are the age public keys, or the files of the public keys one per line
+type recipients []string
+
+func (r *recipients) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *recipients) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+func (r recipients) parse() ([]age.Recipient, error) {
+	var result []age.Recipient
+	for _, value := range r {
+		if strings.HasPrefix(value, "age1") {
+			recipient, err := age.ParseX25519Recipient(value)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, recipient)
+			continue
+		}
+		f, err := os.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s is neither an age public key nor a file, %w", value, err)
+		}
+		parsed, err := age.ParseRecipients(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recipients from %s, %w", value, err)
+		}
+		result = append(result, parsed...)
+	}
+	return result, nil
+}
+
+// encryptionOptions are the flags to encrypt the result, for the commands writing the discovered apps
+type encryptionOptions struct {
+	recipients recipients
+	passphrase bool
+	armor      bool
+}
+
+func (e *encryptionOptions) register(fs *flag.FlagSet) {
+	fs.Var(&e.recipients, "recipient", "Encrypt the result to the age public key, or the file of public keys, can be repeated")
+	fs.BoolVar(&e.passphrase, "passphrase", false, "Encrypt the result with a passphrase, read from env "+PassphraseEnv+" or prompted")
+	fs.BoolVar(&e.armor, "armor", false, "Encrypt to the PEM encoded text, e.g. to be pasted into a ticket")
+}
+
+func (e *encryptionOptions) enabled() bool {
+	return len(e.recipients) > 0 || e.passphrase
+}
+
+// ageRecipients parses the recipients, or prompts the passphrase, before the long discovery begins
+func (e *encryptionOptions) ageRecipients(stderr io.Writer) ([]age.Recipient, error) {
+	switch {
+	case !e.enabled():
+		return nil, nil
+	case len(e.recipients) > 0 && e.passphrase:
+		return nil, errors.New("-recipient and -passphrase cannot be used together")
+	case e.passphrase:
+		passphrase, err := readPassphrase(stderr, true)
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	default:
+		return e.recipients.parse()
+	}
+}
+
+// wrap encrypts everything written to the output, the encryption is finished when the output is closed
+func (e *encryptionOptions) wrap(output *Output, ageRecipients []age.Recipient) error {
+	if len(ageRecipients) == 0 {
+		return nil
+	}
+
+	var w = &encryptedWriter{dst: output.writer}
+	var dst = output.writer
+	if e.armor {
+		w.armor = armor.NewWriter(output.writer)
+		dst = w.armor
+	}
+	encrypted, err := age.Encrypt(dst, ageRecipients...)
+	if err != nil {
+		return err
+	}
+	w.WriteCloser = encrypted
+	output.writer = w
+	return nil
+}
+
+type encryptedWriter struct {
+	io.WriteCloser
+	armor io.WriteCloser
+	dst   io.Writer
+}
+
+// Close flushes the last chunk of the encryption and closes the underlying file
+func (w *encryptedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if w.armor != nil {
+		if e := w.armor.Close(); err == nil {
+			err = e
+		}
+	}
+	if closer, ok := w.dst.(io.Closer); ok && w.dst != os.Stdout {
+		if e := closer.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// decrypt reads the age encrypted content, either binary or armored
+func decrypt(content []byte, identities ...age.Identity) (io.Reader, error) {
+	var src io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(content)))
+	}
+	return age.Decrypt(src, identities...)
+}
+
+// readPassphrase reads the passphrase from env, or prompts it on the terminal
+func readPassphrase(stderr io.Writer, confirm bool) (string, error) {
+	return readSecret(stderr, PassphraseEnv, "passphrase", confirm)
+}
+
+// readSecret reads the secret from env, or prompts it on the terminal, so it is never in the args seen by ps
+func readSecret(stderr io.Writer, env string, name string, confirm bool) (string, error) {
+	if secret, ok := os.LookupEnv(env); ok {
+		return secret, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no %s, set env %s when not running in a terminal", name, env)
+	}
+
+	fmt.Fprintf(stderr, "Enter %s: ", name)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(secret) == 0 {
+		return "", fmt.Errorf("empty %s", name)
+	}
+	if confirm {
+		fmt.Fprintf(stderr, "Confirm %s: ", name)
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(stderr)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(secret, again) {
+			return "", fmt.Errorf("%ss do not match", name)
+		}
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"filippo.io/age"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Encrypted result", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	// writeEncrypted writes the result as the discover command does with the encryption flags
+	writeEncrypted := func(encryption encryptionOptions) string {
+		filename := filepath.Join(dir, "result.json.age")
+		recipients, err := encryption.ageRecipients(GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		output, err := NewOutput(filename, "json-full")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(encryption.wrap(output, recipients)).Should(Succeed())
+		Expect(writeScan(output, newTestScanResult())).Should(Succeed())
+		Expect(output.Close()).Should(Succeed())
+		content, err := os.ReadFile(filename)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(isEncrypted(content)).Should(BeTrue())
+		return filename
+	}
+
+	When("encrypted to a recipient", func() {
+		var identityFile string
+
+		BeforeEach(func() {
+			identity, err := age.GenerateX25519Identity()
+			Expect(err).ShouldNot(HaveOccurred())
+			identityFile = filepath.Join(dir, "key.txt")
+			Expect(os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600)).Should(Succeed())
+			recipientFile := filepath.Join(dir, "key.pub")
+			Expect(os.WriteFile(recipientFile, []byte(identity.Recipient().String()+"\n"), 0600)).Should(Succeed())
+		})
+
+		DescribeTable("should be read back by the identity",
+			func(armored bool) {
+				filename := writeEncrypted(encryptionOptions{recipients: recipients{filepath.Join(dir, "key.pub")}, armor: armored})
+
+				loaded, err := loadScan(filename, &decryptionOptions{identityFiles: configFiles{identityFile}, stderr: GinkgoWriter})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(loaded.Apps).Should(Equal(newTestScanResult().Apps))
+				Expect(loaded.Errors).Should(Equal(newTestScanResult().Errors))
+			},
+			Entry("binary", false),
+			Entry("armored", true),
+		)
+
+		It("should not be read by another identity", func() {
+			filename := writeEncrypted(encryptionOptions{recipients: recipients{filepath.Join(dir, "key.pub")}})
+			other, err := age.GenerateX25519Identity()
+			Expect(err).ShouldNot(HaveOccurred())
+			otherFile := filepath.Join(dir, "other.txt")
+			Expect(os.WriteFile(otherFile, []byte(other.String()+"\n"), 0600)).Should(Succeed())
+
+			_, err = loadScan(filename, &decryptionOptions{identityFiles: configFiles{otherFile}, stderr: GinkgoWriter})
+			Expect(err).Should(MatchError(ContainSubstring("cannot decrypt")))
+		})
+
+		It("should not be read without the decryption", func() {
+			filename := writeEncrypted(encryptionOptions{recipients: recipients{filepath.Join(dir, "key.pub")}})
+
+			_, err := loadScan(filename, nil)
+			Expect(err).Should(MatchError(ContainSubstring("encrypted")))
+		})
+	})
+
+	When("encrypted with a passphrase", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv(PassphraseEnv, "correct horse battery staple")
+		})
+
+		It("should be read back by the passphrase", func() {
+			filename := writeEncrypted(encryptionOptions{passphrase: true, armor: true})
+
+			loaded, err := loadScan(filename, &decryptionOptions{stderr: GinkgoWriter})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(loaded.Apps).Should(Equal(newTestScanResult().Apps))
+		})
+
+		It("should not be read by a wrong passphrase", func() {
+			filename := writeEncrypted(encryptionOptions{passphrase: true})
+			GinkgoT().Setenv(PassphraseEnv, "wrong")
+
+			_, err := loadScan(filename, &decryptionOptions{stderr: GinkgoWriter})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
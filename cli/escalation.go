@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"strings"
+)
+
+// EscalationPasswordEnv gives the password of sudo or su without prompting
+const EscalationPasswordEnv = "JAVA_DISCOVERY_ESCALATION_PASSWORD"
+
+// escalationOptions are the flags of how the commands denied to the login user are run with the privileges
+type escalationOptions struct {
+	method   string
+	user     string
+	password bool
+	// secret is the password read by readPassword, never from the args
+	secret string
+}
+
+func (e *escalationOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&e.method, "escalation", string(springboot.EscalationSudo), "How the commands denied to the login user are run, none never escalates, sudo is passwordless sudo, sudo-password feeds the password to sudo -S, su answers the password of su -c")
+	fs.StringVar(&e.user, "escalation-user", "", "User to run the denied commands as, e.g. the service account of the apps, default root")
+	fs.BoolVar(&e.password, "escalation-password", false, "Password for sudo-password or su, read from env "+EscalationPasswordEnv+" or prompted, default the password of -password")
+}
+
+// readPassword reads the password of the escalation before the discovery begins, if -escalation-password is given
+func (e *escalationOptions) readPassword(stderr io.Writer) error {
+	if !e.password {
+		return nil
+	}
+	secret, err := readSecret(stderr, EscalationPasswordEnv, "escalation password", false)
+	if err != nil {
+		return err
+	}
+	e.secret = secret
+	return nil
+}
+
+func (e *escalationOptions) escalation() (*springboot.Escalation, error) {
+	method := springboot.EscalationMethod(strings.ToLower(e.method))
+	if !springboot.Contains(springboot.EscalationMethods, method) {
+		return nil, fmt.Errorf("unsupported escalation %s, supported: %s, %s, %s, %s", e.method, springboot.EscalationNone, springboot.EscalationSudo, springboot.EscalationSudoPassword, springboot.EscalationSu)
+	}
+	if method == springboot.EscalationNone && (len(e.user) > 0 || e.password) {
+		return nil, fmt.Errorf("-escalation-user and -escalation-password cannot be used with -escalation none")
+	}
+	return &springboot.Escalation{Method: method, User: e.user, Password: e.secret}, nil
+}
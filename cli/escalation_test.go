@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"github.com/Azure/discover-java-apps/springboot"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"io"
+)
+
+var _ = Describe("Escalation", func() {
+	It("should read the password from env, never from the args", func() {
+		GinkgoT().Setenv(EscalationPasswordEnv, "secret")
+		var e escalationOptions
+		fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		e.register(fs)
+		Expect(fs.Parse([]string{"-escalation", "su", "-escalation-password", "rootpassword"})).Should(Succeed())
+		Expect(fs.Args()).Should(Equal([]string{"rootpassword"}))
+
+		Expect(e.readPassword(GinkgoWriter)).Should(Succeed())
+		escalation, err := e.escalation()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(escalation).Should(Equal(&springboot.Escalation{Method: springboot.EscalationSu, Password: "secret"}))
+	})
+
+	It("should use the password of the credential without -escalation-password", func() {
+		GinkgoT().Setenv(EscalationPasswordEnv, "secret")
+		e := escalationOptions{method: string(springboot.EscalationSudoPassword)}
+		Expect(e.readPassword(GinkgoWriter)).Should(Succeed())
+		Expect(e.escalation()).Should(Equal(&springboot.Escalation{Method: springboot.EscalationSudoPassword}))
+	})
+
+	It("should reject the password with -escalation none", func() {
+		_, err := (&escalationOptions{method: string(springboot.EscalationNone), password: true}).escalation()
+		Expect(err).Should(MatchError(ContainSubstring("-escalation none")))
+	})
+})
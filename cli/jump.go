@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// JumpPasswordEnv gives the password of the jump hosts without prompting
+const JumpPasswordEnv = "JAVA_DISCOVERY_JUMP_PASSWORD"
+
+// jumpOptions are the flags to reach the servers through the jump hosts, as ProxyJump of OpenSSH
+type jumpOptions struct {
+	specs      configFiles
+	identities configFiles
+	knownHosts string
+	password   bool
+	// secret is the password read by readPassword, never from the args
+	secret string
+}
+
+// jumpRule gives the jump hosts to the servers matching the pattern, empty pattern matches all
+type jumpRule struct {
+	pattern   string
+	jumpHosts []springboot.JumpHost
+}
+
+// jumpIdentity is the private key of the jump hosts matching the pattern, empty pattern matches all
+type jumpIdentity struct {
+	pattern string
+	signer  ssh.Signer
+}
+
+func (j *jumpOptions) register(fs *flag.FlagSet) {
+	fs.Var(&j.specs, "jump", "Jump hosts to reach the servers, [pattern=][user@]host[:port][,...] as ProxyJump, the pattern matches the server e.g. 10.1.*, the first matched one wins, can be repeated")
+	fs.BoolVar(&j.password, "jump-password", false, "Password for the jump hosts, read from env "+JumpPasswordEnv+" or prompted, default the credential of the server")
+	fs.Var(&j.identities, "jump-identity", "Private key file in PEM for the jump hosts, [pattern=]file, the pattern matches the jump host e.g. bastion*, the first matched one wins, can be repeated")
+	fs.StringVar(&j.knownHosts, "jump-known-hosts", "", "known_hosts file to verify the jump hosts, e.g. ~/.ssh/known_hosts, default they are verified the same way as the servers")
+}
+
+// readPassword reads the password of the jump hosts before the discovery begins, if -jump-password is given
+func (j *jumpOptions) readPassword(stderr io.Writer) error {
+	if !j.password {
+		return nil
+	}
+	secret, err := readSecret(stderr, JumpPasswordEnv, "jump host password", false)
+	if err != nil {
+		return err
+	}
+	j.secret = secret
+	return nil
+}
+
+// sshOption resolves the jump hosts by the server, nil if no jump host
+func (j *jumpOptions) sshOption() (springboot.SshOption, error) {
+	resolver, err := j.resolver()
+	if err != nil || resolver == nil {
+		return nil, err
+	}
+	return springboot.WithJumpHostResolver(resolver), nil
+}
+
+// resolver gives the jump hosts of the first rule matching the server, nil if no jump host
+func (j *jumpOptions) resolver() (springboot.JumpHostResolver, error) {
+	if len(j.specs) == 0 {
+		if j.password || len(j.identities) > 0 || len(j.knownHosts) > 0 {
+			return nil, fmt.Errorf("-jump-password, -jump-identity and -jump-known-hosts require -jump")
+		}
+		return nil, nil
+	}
+
+	identities, err := j.readIdentities()
+	if err != nil {
+		return nil, err
+	}
+	var cb ssh.HostKeyCallback
+	if len(j.knownHosts) > 0 {
+		if cb, err = knownhosts.New(j.knownHosts); err != nil {
+			return nil, fmt.Errorf("cannot read -jump-known-hosts %s, %w", j.knownHosts, err)
+		}
+	}
+
+	var rules []jumpRule
+	for _, spec := range j.specs {
+		var rule jumpRule
+		if i := strings.Index(spec, "="); i >= 0 {
+			rule.pattern, spec = strings.TrimSpace(spec[:i]), spec[i+1:]
+			if _, err := path.Match(rule.pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %s of -jump, %w", rule.pattern, err)
+			}
+		}
+		jumpHosts, err := springboot.ParseProxyJump(spec)
+		if err != nil {
+			return nil, err
+		}
+		if len(jumpHosts) == 0 {
+			return nil, fmt.Errorf("no jump host in -jump %s", spec)
+		}
+		for i := range jumpHosts {
+			jumpHosts[i].Password = j.secret
+			for _, identity := range identities {
+				if matched, _ := path.Match(identity.pattern, jumpHosts[i].Server); len(identity.pattern) == 0 || matched {
+					jumpHosts[i].Signer = identity.signer
+					break
+				}
+			}
+			jumpHosts[i].HostKeyCallback = cb
+		}
+		rule.jumpHosts = jumpHosts
+		rules = append(rules, rule)
+	}
+
+	return func(server string, port int) []springboot.JumpHost {
+		for _, rule := range rules {
+			if len(rule.pattern) == 0 {
+				return rule.jumpHosts
+			}
+			if matched, _ := path.Match(rule.pattern, server); matched {
+				return rule.jumpHosts
+			}
+		}
+		return nil
+	}, nil
+}
+
+// readIdentities reads the private keys of -jump-identity, the keys protected by a passphrase are not supported
+func (j *jumpOptions) readIdentities() ([]jumpIdentity, error) {
+	var identities []jumpIdentity
+	for _, spec := range j.identities {
+		var identity jumpIdentity
+		file := spec
+		if i := strings.Index(spec, "="); i >= 0 {
+			identity.pattern, file = strings.TrimSpace(spec[:i]), spec[i+1:]
+			if _, err := path.Match(identity.pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %s of -jump-identity, %w", identity.pattern, err)
+			}
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if identity.signer, err = ssh.ParsePrivateKey(b); err != nil {
+			return nil, fmt.Errorf("cannot read the private key %s of -jump-identity, %w", file, err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Jump hosts", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	// writeKey writes a private key in PEM, and returns its public key
+	writeKey := func(name string) (string, ssh.PublicKey) {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ShouldNot(HaveOccurred())
+		block, err := ssh.MarshalPrivateKey(private, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		file := filepath.Join(dir, name)
+		Expect(os.WriteFile(file, pem.EncodeToMemory(block), 0600)).Should(Succeed())
+		key, err := ssh.NewPublicKey(public)
+		Expect(err).ShouldNot(HaveOccurred())
+		return file, key
+	}
+
+	It("should resolve the jump hosts by the first matched rule", func() {
+		j := jumpOptions{specs: configFiles{"10.1.*=admin@bastion:2222,gateway", "jump"}}
+		resolver, err := j.resolver()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		hops := resolver("10.1.0.5", 22)
+		Expect(hops).Should(HaveLen(2))
+		Expect(hops[0].Server).Should(Equal("bastion"))
+		Expect(hops[0].Username).Should(Equal("admin"))
+		Expect(hops[0].Port).Should(Equal(2222))
+		Expect(hops[1].Server).Should(Equal("gateway"))
+		Expect(resolver("10.2.0.7", 22)[0].Server).Should(Equal("jump"))
+	})
+
+	It("should give the private key of every jump host by its pattern", func() {
+		bastionKey, bastionPublic := writeKey("bastion_key")
+		defaultKey, defaultPublic := writeKey("default_key")
+		j := jumpOptions{specs: configFiles{"bastion,gateway,other"}, identities: configFiles{"bastion=" + bastionKey, "gate*=" + defaultKey}, secret: "secret"}
+		resolver, err := j.resolver()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		hops := resolver("10.1.0.5", 22)
+		Expect(hops[0].Signer.PublicKey()).Should(Equal(bastionPublic))
+		Expect(hops[1].Signer.PublicKey()).Should(Equal(defaultPublic))
+		Expect(hops[2].Signer).Should(BeNil())
+		for _, hop := range hops {
+			Expect(hop.Password).Should(Equal("secret"))
+		}
+	})
+
+	It("should reject the file which is not a private key", func() {
+		file := filepath.Join(dir, "not_a_key")
+		Expect(os.WriteFile(file, []byte("password"), 0600)).Should(Succeed())
+		_, err := (&jumpOptions{specs: configFiles{"bastion"}, identities: configFiles{file}}).resolver()
+		Expect(err).Should(MatchError(ContainSubstring("-jump-identity")))
+	})
+
+	It("should verify the jump hosts by the known hosts", func() {
+		_, key := writeKey("host_key")
+		_, otherKey := writeKey("other_key")
+		knownHostsFile := filepath.Join(dir, "known_hosts")
+		Expect(os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{knownhosts.Normalize("bastion:2222")}, key)+"\n"), 0600)).Should(Succeed())
+
+		resolver, err := (&jumpOptions{specs: configFiles{"admin@bastion:2222"}, knownHosts: knownHostsFile}).resolver()
+		Expect(err).ShouldNot(HaveOccurred())
+		cb := resolver("10.1.0.5", 22)[0].HostKeyCallback
+		Expect(cb).ShouldNot(BeNil())
+		remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2222}
+		Expect(cb("bastion:2222", remote, key)).Should(Succeed())
+		Expect(cb("bastion:2222", remote, otherKey)).ShouldNot(Succeed())
+		Expect(cb("gateway:22", remote, key)).ShouldNot(Succeed())
+	})
+
+	It("should verify the jump hosts the same way as the servers without the known hosts", func() {
+		resolver, err := (&jumpOptions{specs: configFiles{"bastion"}}).resolver()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resolver("10.1.0.5", 22)[0].HostKeyCallback).Should(BeNil())
+	})
+
+	It("should read the password from env", func() {
+		GinkgoT().Setenv(JumpPasswordEnv, "secret")
+		j := jumpOptions{specs: configFiles{"bastion"}, password: true}
+		Expect(j.readPassword(GinkgoWriter)).Should(Succeed())
+		resolver, err := j.resolver()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resolver("10.1.0.5", 22)[0].Password).Should(Equal("secret"))
+	})
+
+	DescribeTable("should require -jump",
+		func(j jumpOptions) {
+			_, err := j.resolver()
+			Expect(err).Should(MatchError(ContainSubstring("require -jump")))
+		},
+		Entry("password", jumpOptions{password: true}),
+		Entry("identity", jumpOptions{identities: configFiles{"key"}}),
+		Entry("known hosts", jumpOptions{knownHosts: "known_hosts"}),
+	)
+})
@@ -2,38 +2,124 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/Azure/discover-java-apps/springboot"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
+	"io"
 	"os"
-	"time"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	issueHint = "any issue could report to https://github.com/Azure/azure-discovery-java-apps/issues"
+	usage     = `Usage: discovery <command> [flags]
+
+Commands:
+  discover  Discover java apps from the servers, the default command when omitted
+  report    Render a saved json result into another format without re-scanning
+  diff      Compare two saved json results
+  decrypt   Decrypt a result encrypted with -recipient or -passphrase
+  config    Validate or print the config
+  version   Print the version
+
+Run 'discovery <command> -h' for the flags of a command
+`
+)
+
+type command struct {
+	name string
+	run  func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "discover", run: runDiscoverCommand},
+	{name: "report", run: runReportCommand},
+	{name: "diff", run: runDiffCommand},
+	{name: "decrypt", run: runDecryptCommand},
+	{name: "config", run: runConfigCommand},
+	{name: "version", run: runVersionCommand},
+}
+
 func main() {
-	var server string
-	var port int
-	var username string
-	var password string
-	var filename string
-	var format string
-	flag.StringVar(&server, "server", "", "Target server to be discovered")
-	flag.StringVar(&username, "username", "", "Username for ssh login")
-	flag.StringVar(&password, "password", "", "Password for ssh login")
-	flag.IntVar(&port, "port", 22, "The ssh port, default 22")
-
-	flag.StringVar(&filename, "file", "", "File name for result, default console")
-	flag.StringVar(&format, "format", "json", "Output format, default json")
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stderr, usage)
+		return 0
+	}
+
+	if strings.HasPrefix(args[0], "-") {
+		// keep the flat flags working, e.g. discovery -server 'servername'
+		return runDiscoverCommand(args, stdout, stderr)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command %s\n\n%s", args[0], usage)
+	return 2
+}
+
+// globalOptions are the flags shared by every command
+type globalOptions struct {
+	logFile       string
+	logLevel      string
+	cfgFiles      configFiles
+	filename      string
+	format        string
+	template      string
+	columns       columns
+	separator     string
+	encryption    encryptionOptions
+	anonymization anonymizationOptions
+}
+
+// register adds the global flags to the command, the first format is the default one
+func (g *globalOptions) register(fs *flag.FlagSet, formats ...string) {
+	fs.StringVar(&g.logFile, "log-file", "discovery.log", "File name for running log")
+	fs.StringVar(&g.logLevel, "log-level", "debug", "Log level, one of debug, info, warn, error")
+	fs.Var(&g.cfgFiles, "config", "Config file overlaid on the defaults, can be repeated and later file wins")
+	fs.StringVar(&g.filename, "file", "", "File name for result, default console")
+	fs.StringVar(&g.format, "format", formats[0], "Output format, one of "+strings.Join(formats, ", "))
+	for _, format := range formats {
+		switch format {
+		case "template":
+			fs.StringVar(&g.template, "template", "", "Template file for the template format, or one of the bundled templates: "+strings.Join(bundledTemplates(), ", "))
+		case "csv":
+			fs.Var(&g.columns, "columns", "Comma separated json paths of the full app model for the csv format, e.g. appName,runtime.server,runtime.bindingPorts")
+			fs.StringVar(&g.separator, "separator", defaultSeparator, "Separator to flatten the lists and maps in a csv column")
+		}
+	}
+}
+
+// setup builds the logger into context and makes the config effective
+func (g *globalOptions) setup() (context.Context, error) {
+	level, err := zapcore.ParseLevel(g.logLevel)
+	if err != nil {
+		return nil, err
+	}
 	cfg := &zap.Config{
 		Encoding:         "console",
-		Level:            zap.NewAtomicLevelAt(zapcore.DebugLevel),
-		OutputPaths:      []string{"discovery.log"},
-		ErrorOutputPaths: []string{"discovery.log"},
+		Level:            zap.NewAtomicLevelAt(level),
+		OutputPaths:      []string{g.logFile},
+		ErrorOutputPaths: []string{g.logFile},
 		EncoderConfig: zapcore.EncoderConfig{
 			MessageKey:     "message",
 			LevelKey:       "level",
@@ -45,55 +131,96 @@ func main() {
 			EncodeDuration: zapcore.MillisDurationEncoder,
 		},
 	}
-	logger, _ := cfg.Build()
-	ctx := logr.NewContext(context.Background(), zapr.NewLogger(logger))
-	azureLogger := springboot.GetAzureLogger(ctx, map[string]string{
-		"server": server,
-	})
+	logger, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+	log := zapr.NewLogger(logger)
+	ctx := logr.NewContext(context.Background(), log)
 
-	var serverConnectInfo = springboot.ServerConnectionInfo{
-		Server: server,
-		Port:   port,
+	if err = useConfigFiles(g.cfgFiles); err != nil {
+		return nil, err
+	}
+	for _, warning := range springboot.YamlCfgWarnings {
+		log.Info("config env override ignored", "env", warning.Path, "cause", errors.Unwrap(warning).Error())
 	}
+	return ctx, nil
+}
 
-	output, err := NewOutput(filename, format)
+// output creates the output, the encrypted output must be closed to finish the encryption
+func (g *globalOptions) output(stdout io.Writer, stderr io.Writer) (*Output, error) {
+	// fail fast on the bad template or recipients, before the long discovery
+	if strings.EqualFold(g.format, "template") {
+		if _, err := loadTemplate(g.template); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.columns) > 0 {
+		if !strings.EqualFold(strings.TrimSpace(g.format), "csv") {
+			return nil, fmt.Errorf("-columns is only supported by -format csv, not %s", g.format)
+		}
+		if err := g.columns.validate(); err != nil {
+			return nil, err
+		}
+	}
+	anonymizer, err := g.anonymization.anonymizer(stderr)
+	if err != nil {
+		return nil, err
+	}
+	ageRecipients, err := g.encryption.ageRecipients(stderr)
 	if err != nil {
-		azureLogger.Error(err, "error when creating output", "filename", filename)
-		os.Exit(1)
+		return nil, err
+	}
+	if anonymizer != nil {
+		anonymizer.encryption, anonymizer.recipients = &g.encryption, ageRecipients
 	}
 
-	DoSpringBootDiscovery(ctx, serverConnectInfo, NewUsernamePasswordCredentialProvider(username, password), output)
+	var output = &Output{writer: stdout, format: g.format}
+	if len(g.filename) > 0 {
+		if output, err = NewOutput(g.filename, g.format); err != nil {
+			return nil, err
+		}
+	}
+	output.template = g.template
+	output.columns = g.columns
+	output.separator = g.separator
+	output.anonymizer = anonymizer
+	if err = g.encryption.wrap(output, ageRecipients); err != nil {
+		output.Close()
+		return nil, err
+	}
+	return output, nil
 }
 
-func DoSpringBootDiscovery(ctx context.Context, info springboot.ServerConnectionInfo, credentialProvider springboot.CredentialProvider, output *Output) {
-	azureLogger := springboot.GetAzureLogger(ctx)
-	var executor = springboot.NewSpringBootDiscoveryExecutor(
-		credentialProvider,
-		springboot.DefaultServerConnectorFactory(
-			springboot.WithConnectionTimeout(time.Duration(5)*time.Second),
-			springboot.WithHostKeyCallback(MemoryHostKeyCallbackFunction()),
-		),
-		springboot.YamlCfg,
-	)
-
-	apps, err := executor.Discover(ctx, info)
-	if err != nil {
-		azureLogger.Error(err, "failed to discover")
-		fmt.Println("Error occurred during discovery, please check discovery.log, any issue could report to https://github.com/Azure/azure-discovery-java-apps/issues")
-		os.Exit(1)
+func newFlagSet(name string, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fmt.Fprintln(stderr, "\nFlags:")
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	if len(apps) == 0 {
-		fmt.Print("no app discovered from " + info.Server)
-		os.Exit(0)
+// parseFlags parses the flags, the positional args are allowed before the flags as well
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
+}
 
-	var converter = NewSpringBootAppConverter()
-	var cliApps = converter.Convert(apps)
-
-	if err = output.Write(cliApps); err != nil {
-		azureLogger.Error(err, "error when write to target file")
-		fmt.Println("Error occurred while writing to file, please check discovery.log, any issue could report to https://github.com/Azure/azure-discovery-java-apps/issues")
-		os.Exit(1)
+func exitCode(err error) int {
+	if err == flag.ErrHelp {
+		return 0
 	}
+	return 2
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"path/filepath"
+)
+
+var _ = Describe("Commands", func() {
+	// runArgs runs the cli with the log file in a temp dir, so the tests never write discovery.log in the tree
+	runArgs := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		for i, arg := range args {
+			if arg == "{log}" {
+				args[i] = filepath.Join(GinkgoT().TempDir(), "discovery.log")
+			}
+		}
+		rc := run(args, &stdout, &stderr)
+		return rc, stdout.String(), stderr.String()
+	}
+
+	DescribeTable("should dispatch the command",
+		func(args []string, rc int, stdout string, stderr string) {
+			actualRc, actualStdout, actualStderr := runArgs(args...)
+			Expect(actualRc).Should(Equal(rc))
+			Expect(actualStdout).Should(ContainSubstring(stdout))
+			Expect(actualStderr).Should(ContainSubstring(stderr))
+		},
+		Entry("no command", nil, 2, "", "Usage: discovery <command> [flags]"),
+		Entry("-h", []string{"-h"}, 0, "", "Usage: discovery <command> [flags]"),
+		Entry("--help", []string{"--help"}, 0, "", "Run 'discovery <command> -h'"),
+		Entry("help", []string{"help"}, 0, "", "Commands:"),
+		Entry("unknown command", []string{"scan"}, 2, "", "unknown command scan"),
+		Entry("version", []string{"version"}, 0, "discovery ", ""),
+		Entry("-h of a command", []string{"report", "-h"}, 0, "", "Usage: discovery report [flags] <result.json>"),
+		Entry("-h of discover", []string{"discover", "-h"}, 0, "", "-server"),
+		Entry("unknown flag of a command", []string{"version", "-server", "a"}, 2, "", "flag provided but not defined: -server"),
+		Entry("missing positional of a command", []string{"report", "-log-file", "{log}"}, 2, "", "Usage: discovery report"),
+		Entry("discover", []string{"discover", "-log-file", "{log}", "-dry-run", "-server", "10.0.0.4", "-username", "azureuser"}, 0, "10.0.0.4", ""),
+		Entry("discover without server", []string{"discover", "-log-file", "{log}", "-username", "azureuser"}, 2, "", "no server specified"),
+		Entry("legacy flat flags", []string{"-log-file", "{log}", "-dry-run", "-server", "10.0.0.4", "-username", "azureuser"}, 0, "10.0.0.4", ""),
+		Entry("legacy flat flags without server", []string{"-log-file", "{log}", "-username", "azureuser"}, 2, "", "no server specified"),
+		Entry("legacy unknown flag", []string{"-bogus"}, 2, "", "flag provided but not defined: -bogus"),
+	)
+
+	DescribeTable("should parse the flags around the positional args",
+		func(args []string, positional []string, format string) {
+			var g globalOptions
+			fs := newFlagSet("report", reportUsage, GinkgoWriter)
+			g.register(fs, supportedFormats...)
+			actual, err := parseFlags(fs, args)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(actual).Should(Equal(positional))
+			Expect(g.format).Should(Equal(format))
+		},
+		Entry("flags first", []string{"-format", "csv", "a.json"}, []string{"a.json"}, "csv"),
+		Entry("flags last", []string{"a.json", "-format", "csv"}, []string{"a.json"}, "csv"),
+		Entry("flags between", []string{"a.json", "-format", "csv", "b.json"}, []string{"a.json", "b.json"}, "csv"),
+		Entry("no flag", []string{"a.json"}, []string{"a.json"}, supportedFormats[0]),
+		Entry("nothing", nil, nil, supportedFormats[0]),
+	)
+})
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/Azure/discover-java-apps/springboot"
+	"time"
+)
+
+var testScanTime = time.Date(2023, 2, 5, 9, 24, 40, 0, time.UTC)
+
+// newTestScanResult gives a scan of one host with one app and a failed host
+func newTestScanResult() *ScanResult {
+	app := &springboot.SpringBootApp{
+		AppName:                   "hellospring",
+		AppType:                   springboot.SpringBootFatJar,
+		Artifact:                  &springboot.Artifact{Group: "com.example", Name: "hellospring", Version: "0.0.1-SNAPSHOT"},
+		ApplicationConfigurations: map[string]string{"application.yml": "spring.datasource.url: jdbc:postgresql://db-prod-01.corp.local:5432/orders"},
+		BuildJdkVersion:           "11",
+		Checksum:                  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Dependencies:              []string{"spring-boot-2.7.5.jar", "postgresql-42.5.0.jar"},
+		JarFileLocation:           "/home/azureuser/hellospring.jar",
+		JarSize:                   52 * 1024 * 1024,
+		LastModifiedTime:          testScanTime,
+		SpringBootVersion:         "2.7.5",
+		Runtime: &springboot.Runtime{
+			Server:            "10.0.0.4",
+			Uid:               1000,
+			Pid:               42,
+			RuntimeJdkVersion: "11.0.16",
+			AppPort:           8080,
+			JavaCmd:           "/usr/lib/jvm/java-11-openjdk-amd64/bin/java",
+			Environments:      []string{"HOSTNAME=app-vm-01", "SPRING_REDIS_HOST=redis-01.corp.local"},
+			JvmOptions:        []string{"-Xmx512m", "-Dlogging.file=/home/azureuser/logs/app.log"},
+			JvmMemory:         512 * 1024 * 1024,
+			OsName:            "ubuntu",
+			OsVersion:         "18.04",
+			BindingPorts:      []int{8080, 8081},
+		},
+	}
+	return &ScanResult{
+		SchemaVersion: ScanSchemaVersion,
+		ToolVersion:   "v1.0.0",
+		ScanTime:      testScanTime,
+		Hosts: []*HostFacts{
+			{Server: "10.0.0.4", Port: 22, OsName: "ubuntu", OsVersion: "18.04", AppCount: 1, StartTime: testScanTime, Succeeded: true},
+			{Server: "10.0.0.5", Port: 22, StartTime: testScanTime},
+		},
+		Apps:   []*springboot.SpringBootApp{app},
+		Errors: []*ScanError{{Server: "10.0.0.5", Message: "failed to connect to target server"}},
+	}
+}
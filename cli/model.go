@@ -39,6 +39,9 @@ func (s springBootAppConverter) Convert(apps []*springboot.SpringBootApp) []*Cli
 	var results []*CliApp
 
 	for _, app := range apps {
+		if app == nil {
+			continue
+		}
 
 		var appType = "ExecutableJar"
 
@@ -46,23 +49,72 @@ func (s springBootAppConverter) Convert(apps []*springboot.SpringBootApp) []*Cli
 			appType = "SpringBoot"
 		}
 
-		results = append(results, &CliApp{
-			Server:            app.Runtime.Server,
+		var cliApp = &CliApp{
 			AppName:           app.AppName,
 			AppType:           appType,
-			AppPort:           app.Runtime.AppPort,
-			ArtifactGroup:     app.Artifact.Group,
-			ArtifactName:      app.Artifact.Name,
-			ArtifactVersion:   app.Artifact.Version,
 			SpringBootVersion: app.SpringBootVersion,
 			BuildJdkVersion:   app.BuildJdkVersion,
-			RuntimeJdkVersion: app.Runtime.RuntimeJdkVersion,
 			JarFileLocation:   app.JarFileLocation,
-			OsName:            app.Runtime.OsName,
-			OsVersion:         app.Runtime.OsVersion,
 			JarSize:           app.JarSize / springboot.KiB,
-			JvmMemory:         app.Runtime.JvmMemory / springboot.MiB,
 			LastModifiedTime:  app.LastModifiedTime.UTC().Format(time.RFC3339),
+		}
+		if app.Artifact != nil {
+			cliApp.ArtifactGroup = app.Artifact.Group
+			cliApp.ArtifactName = app.Artifact.Name
+			cliApp.ArtifactVersion = app.Artifact.Version
+		}
+		if app.Runtime != nil {
+			cliApp.Server = app.Runtime.Server
+			cliApp.AppPort = app.Runtime.AppPort
+			cliApp.RuntimeJdkVersion = app.Runtime.RuntimeJdkVersion
+			cliApp.OsName = app.Runtime.OsName
+			cliApp.OsVersion = app.Runtime.OsVersion
+			cliApp.JvmMemory = app.Runtime.JvmMemory / springboot.MiB
+		}
+		results = append(results, cliApp)
+	}
+	return results
+}
+
+type cliAppConverter struct {
+}
+
+// NewCliAppConverter converts the saved CliApp back, so a saved result can be rendered again
+func NewCliAppConverter() Converter[[]*CliApp, []*springboot.SpringBootApp] {
+	return &cliAppConverter{}
+}
+
+func (c cliAppConverter) Convert(cliApps []*CliApp) []*springboot.SpringBootApp {
+	var results []*springboot.SpringBootApp
+
+	for _, cliApp := range cliApps {
+		var appType = springboot.ExecutableJar
+		if cliApp.AppType == "SpringBoot" {
+			appType = springboot.SpringBootFatJar
+		}
+		lastModifiedTime, _ := time.Parse(time.RFC3339, cliApp.LastModifiedTime)
+
+		results = append(results, &springboot.SpringBootApp{
+			AppName:           cliApp.AppName,
+			AppType:           appType,
+			SpringBootVersion: cliApp.SpringBootVersion,
+			BuildJdkVersion:   cliApp.BuildJdkVersion,
+			JarFileLocation:   cliApp.JarFileLocation,
+			JarSize:           cliApp.JarSize * springboot.KiB,
+			LastModifiedTime:  lastModifiedTime,
+			Artifact: &springboot.Artifact{
+				Group:   cliApp.ArtifactGroup,
+				Name:    cliApp.ArtifactName,
+				Version: cliApp.ArtifactVersion,
+			},
+			Runtime: &springboot.Runtime{
+				Server:            cliApp.Server,
+				AppPort:           cliApp.AppPort,
+				RuntimeJdkVersion: cliApp.RuntimeJdkVersion,
+				JvmMemory:         cliApp.JvmMemory * springboot.MiB,
+				OsName:            cliApp.OsName,
+				OsVersion:         cliApp.OsVersion,
+			},
 		})
 	}
 	return results
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"io"
+	"strings"
+)
+
+const (
+	appRecord   = "app"
+	errorRecord = "error"
+)
+
+// StreamRecord is a line of the ndjson output, either an app or an error
+type StreamRecord struct {
+	Type    string                    `json:"type"`
+	Server  string                    `json:"server"`
+	App     *springboot.SpringBootApp `json:"app,omitempty"`
+	Message string                    `json:"message,omitempty"`
+}
+
+func newStreamRecord(server string, app *springboot.SpringBootApp, err error) *StreamRecord {
+	if err != nil {
+		return &StreamRecord{Type: errorRecord, Server: server, Message: err.Error()}
+	}
+	return &StreamRecord{Type: appRecord, Server: server, App: app}
+}
+
+// stream tells whether the records are written one by one as soon as discovered
+func (o *Output) stream() bool {
+	return strings.EqualFold(strings.TrimSpace(o.format), "ndjson")
+}
+
+// writeNdjson writes the records one per line, a ScanResult is written as the records of its apps and errors
+func (o *Output) writeNdjson(records any, writer io.Writer) error {
+	var lines []*StreamRecord
+	switch r := records.(type) {
+	case *StreamRecord:
+		lines = append(lines, r)
+	case *ScanResult:
+		for _, app := range r.Apps {
+			if app == nil {
+				continue
+			}
+			var server string
+			if app.Runtime != nil {
+				server = app.Runtime.Server
+			}
+			lines = append(lines, newStreamRecord(server, app, nil))
+		}
+		for _, e := range r.Errors {
+			lines = append(lines, &StreamRecord{Type: errorRecord, Server: e.Server, Message: e.Message})
+		}
+	default:
+		return fmt.Errorf("ndjson format is not supported for %T", records)
+	}
+
+	for _, line := range lines {
+		b, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		if _, err = writer.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readNdjson reads the ndjson output back into a ScanResult
+func readNdjson(content []byte) (*ScanResult, error) {
+	result := NewScanResult()
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record StreamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d, %w", line, err)
+		}
+		switch record.Type {
+		case appRecord:
+			if record.App == nil {
+				return nil, fmt.Errorf("line %d, app record without app", line)
+			}
+			result.Apps = append(result.Apps, record.App)
+		case errorRecord:
+			result.Errors = append(result.Errors, &ScanError{Server: record.Server, Message: record.Message})
+		default:
+			return nil, fmt.Errorf("line %d, unknown record type %q", line, record.Type)
+		}
+	}
+	return result, scanner.Err()
+}
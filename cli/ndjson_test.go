@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Ndjson output", func() {
+	It("should read back the records streamed by the output", func() {
+		result := newTestScanResult()
+		filename := filepath.Join(GinkgoT().TempDir(), "result.ndjson")
+		output, err := NewOutput(filename, "ndjson")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output.Write(newStreamRecord("10.0.0.4", result.Apps[0], nil))).Should(Succeed())
+		Expect(output.Write(newStreamRecord("10.0.0.5", nil, errors.New("failed to connect to target server")))).Should(Succeed())
+		Expect(output.Close()).Should(Succeed())
+
+		loaded, err := loadScan(filename, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(loaded.Apps).Should(Equal(result.Apps))
+		Expect(loaded.Errors).Should(Equal(result.Errors))
+	})
+
+	It("should read back the scan written as ndjson", func() {
+		result := newTestScanResult()
+		filename := filepath.Join(GinkgoT().TempDir(), "result.ndjson")
+		output, err := NewOutput(filename, "ndjson")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output.Write(result)).Should(Succeed())
+		Expect(output.Close()).Should(Succeed())
+
+		loaded, err := loadScan(filename, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(loaded.Apps).Should(Equal(result.Apps))
+		Expect(loaded.Errors).Should(Equal(result.Errors))
+	})
+
+	It("should reject an app record without app", func() {
+		filename := filepath.Join(GinkgoT().TempDir(), "result.ndjson")
+		Expect(os.WriteFile(filename, []byte(`{"type":"app","server":"10.0.0.4"}`+"\n"), 0600)).Should(Succeed())
+
+		_, err := loadScan(filename, nil)
+		Expect(err).Should(MatchError(ContainSubstring("line 1, app record without app")))
+	})
+
+	It("should skip the null apps of the json document", func() {
+		filename := filepath.Join(GinkgoT().TempDir(), "result.json")
+		Expect(os.WriteFile(filename, []byte(`{"schemaVersion":"1.0","apps":[null,{"appName":"hellospring"}]}`), 0600)).Should(Succeed())
+
+		loaded, err := loadScan(filename, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(loaded.Apps).Should(HaveLen(1))
+		Expect(loaded.Apps[0].AppName).Should(Equal("hellospring"))
+	})
+})
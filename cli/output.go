@@ -13,9 +13,21 @@ import (
 	"time"
 )
 
+var supportedFormats = []string{"json", "json-full", "ndjson", "csv", "xlsx", "html", "markdown", "azure-migrate", "template"}
+
+// documentFormats are rendered from the complete ScanResult, the other formats from the CliApp projection
+var documentFormats = []string{"json-full", "ndjson", "xlsx", "html", "markdown", "template"}
+
 type Output struct {
 	writer io.Writer
 	format string
+	// template is the template file or the bundled template name for the template format
+	template string
+	// columns are the json paths selected for the csv format, separator flattens the slices and maps in them
+	columns   columns
+	separator string
+	// anonymizer pseudonymizes the result before written, nil if not anonymized
+	anonymizer *Anonymizer
 }
 
 type FieldWithTag struct {
@@ -59,6 +71,27 @@ func NewOutput(filename string, format string) (*Output, error) {
 	return &Output{writer: writer, format: format}, nil
 }
 
+// document tells whether the format is rendered from the complete ScanResult
+func (o *Output) document() bool {
+	if len(o.columns) > 0 && strings.EqualFold(strings.TrimSpace(o.format), "csv") {
+		return true
+	}
+	for _, format := range documentFormats {
+		if strings.EqualFold(strings.TrimSpace(o.format), format) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes the underlying file, if the output is written to a file
+func (o *Output) Close() error {
+	if closer, ok := o.writer.(io.Closer); ok && o.writer != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
+
 func fileWriter(filename string) (io.Writer, error) {
 	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
@@ -71,10 +104,26 @@ func (o *Output) Write(records any) error {
 	var err error
 	switch strings.ToLower(strings.TrimSpace(o.format)) {
 	case "":
-	case "json":
+	case "json", "json-full":
 		err = o.writeJson(records, o.writer)
-	case "csv":
-		err = o.writCSV(records, o.writer)
+	case "ndjson":
+		err = o.writeNdjson(records, o.writer)
+	case "csv", "azure-migrate":
+		if _, ok := records.(*ScanResult); ok {
+			err = o.writeColumns(records, o.writer)
+		} else {
+			err = o.writCSV(records, o.writer)
+		}
+	case "xlsx":
+		err = o.writeXlsx(records, o.writer)
+	case "html":
+		err = o.writeHtml(records, o.writer)
+	case "markdown":
+		err = o.writeMarkdown(records, o.writer)
+	case "template":
+		err = o.writeTemplate(records, o.writer)
+	default:
+		err = fmt.Errorf("unsupported format %s, supported formats: %s", o.format, strings.Join(supportedFormats, ", "))
 	}
 	return err
 }
@@ -111,9 +160,13 @@ func (o *Output) writCSV(records any, writer io.Writer) error {
 	var values []reflect.Value
 	switch refTyp.Kind() {
 	case reflect.Slice:
+		// the header comes from the element type, so it is written for the empty slice as well
+		refTyp = refTyp.Elem()
 		for i := 0; i < refVal.Len(); i++ {
 			if refVal.Index(i).Kind() == reflect.Ptr {
-				values = append(values, refVal.Index(i).Elem())
+				if !refVal.Index(i).IsNil() {
+					values = append(values, refVal.Index(i).Elem())
+				}
 			} else {
 				values = append(values, refVal.Index(i))
 			}
@@ -123,9 +176,15 @@ func (o *Output) writCSV(records any, writer io.Writer) error {
 	default:
 		values = append(values, refVal)
 	}
+	if refTyp.Kind() == reflect.Ptr {
+		refTyp = refTyp.Elem()
+	}
+	if refTyp.Kind() != reflect.Struct {
+		return fmt.Errorf("csv format is not supported for %s", refTyp)
+	}
 
-	for i := 0; i < values[0].Type().NumField(); i++ {
-		field := values[0].Type().Field(i)
+	for i := 0; i < refTyp.NumField(); i++ {
+		field := refTyp.Field(i)
 		fieldWithTags = append(fieldWithTags, FieldWithTag{name: field.Name, tag: field.Tag.Get("csv")})
 	}
 	content = append(content, fieldWithTags.headers())
@@ -134,7 +193,7 @@ func (o *Output) writCSV(records any, writer io.Writer) error {
 		var row []string
 		for _, field := range fields {
 			value := v.FieldByName(field)
-			row = append(row, toString(value))
+			row = append(row, toString(value, o.separator))
 		}
 		content = append(content, row)
 	}
@@ -148,18 +207,34 @@ func (o *Output) writCSV(records any, writer io.Writer) error {
 	return nil
 }
 
-func toString(v reflect.Value) string {
+func toString(v reflect.Value, separator string) string {
 	switch k := v.Kind(); k {
 	case reflect.Invalid:
-		return "<invalid Value>"
+		return ""
 	case reflect.String:
 		return v.String()
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
 	case reflect.Float32, reflect.Float64:
 		return fmt.Sprintf("%.2f", v.Float())
 	case reflect.Bool:
 		return strconv.FormatBool(v.Bool())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return toString(v.Elem(), separator)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		generic, err := toGeneric(v.Interface())
+		if err != nil {
+			return ""
+		}
+		if len(separator) == 0 {
+			separator = defaultSeparator
+		}
+		return flatten(generic, separator)
 	}
 	if v.Type().String() == "time.Time" {
 		return v.Interface().(time.Time).String()
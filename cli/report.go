@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+const reportUsage = `Usage: discovery report [flags] <result.json>
+
+Render a result saved by the discover command into another format without re-scanning
+`
+
+func runReportCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var g globalOptions
+	fs := newFlagSet("report", reportUsage, stderr)
+	g.register(fs, supportedFormats...)
+	g.encryption.register(fs)
+	g.anonymization.register(fs)
+	var decryption decryptionOptions
+	decryption.register(fs, stderr)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err = g.setup(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	result, err := loadScan(positional[0], &decryption)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	output, err := g.output(stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	if err = writeScan(output, result); err != nil {
+		output.Close()
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if err = output.Close(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if err = output.anonymizer.SaveMapping(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
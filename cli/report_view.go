@@ -0,0 +1,215 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const topDependencyCount = 20
+
+//go:embed templates
+var templates embed.FS
+
+// reportView is the model of the human-readable reports
+type reportView struct {
+	ToolVersion     string
+	ScanTime        time.Time
+	AppCount        int
+	Hosts           []hostView
+	SpringBoot      []countView
+	Jdk             []countView
+	TopDependencies []countView
+	Findings        []findingView
+	Errors          []*ScanError
+}
+
+type hostView struct {
+	Server    string
+	Os        string
+	AppCount  int
+	Findings  int
+	Succeeded bool
+}
+
+type countView struct {
+	Name    string
+	Count   int
+	Percent int
+}
+
+type findingView struct {
+	AppId   string
+	Server  string
+	AppName string
+	springboot.Finding
+}
+
+var severityOrder = map[springboot.Severity]int{
+	springboot.SeverityCritical: 0,
+	springboot.SeverityWarning:  1,
+	springboot.SeverityInfo:     2,
+}
+
+func newReportView(result *ScanResult) *reportView {
+	view := &reportView{ToolVersion: result.ToolVersion, ScanTime: result.ScanTime, Errors: result.Errors}
+
+	var hosts = make(map[string]*hostView)
+	var hostOrder []string
+	host := func(server string) *hostView {
+		if h, ok := hosts[server]; ok {
+			return h
+		}
+		hosts[server] = &hostView{Server: server, Succeeded: true}
+		hostOrder = append(hostOrder, server)
+		return hosts[server]
+	}
+	for _, h := range result.Hosts {
+		hv := host(h.Server)
+		hv.Os = strings.TrimSpace(h.OsName + " " + h.OsVersion)
+		hv.Succeeded = h.Succeeded
+	}
+
+	var springBoot, jdk, dependencies = make(map[string]int), make(map[string]int), make(map[string]int)
+	for _, app := range result.Apps {
+		if app == nil {
+			continue
+		}
+		view.AppCount++
+		var runtime = &springboot.Runtime{}
+		if app.Runtime != nil {
+			runtime = app.Runtime
+		}
+
+		hv := host(runtime.Server)
+		hv.AppCount++
+		if len(hv.Os) == 0 {
+			hv.Os = strings.TrimSpace(runtime.OsName + " " + runtime.OsVersion)
+		}
+
+		springBoot[majorMinor(app.SpringBootVersion)]++
+		jdk[jdkMajor(runtime.RuntimeJdkVersion)]++
+		var seen = make(map[string]bool)
+		for _, dep := range app.Dependencies {
+			if name := springboot.DependencyName(dep); !seen[name] {
+				seen[name] = true
+				dependencies[name]++
+			}
+		}
+
+		for _, finding := range springboot.AssessReadiness(app) {
+			hv.Findings++
+			view.Findings = append(view.Findings, findingView{
+				AppId:   springboot.AppId(app),
+				Server:  runtime.Server,
+				AppName: app.AppName,
+				Finding: finding,
+			})
+		}
+	}
+
+	for _, server := range hostOrder {
+		view.Hosts = append(view.Hosts, *hosts[server])
+	}
+	view.SpringBoot = counts(springBoot, view.AppCount, 0)
+	view.Jdk = counts(jdk, view.AppCount, 0)
+	view.TopDependencies = counts(dependencies, view.AppCount, topDependencyCount)
+	sort.SliceStable(view.Findings, func(i, j int) bool {
+		return severityOrder[view.Findings[i].Severity] < severityOrder[view.Findings[j].Severity]
+	})
+	return view
+}
+
+// counts sorts the names by the count in descending order, the percent is relative to total
+func counts(m map[string]int, total int, limit int) []countView {
+	var result []countView
+	for name, count := range m {
+		var percent int
+		if total > 0 {
+			percent = count * 100 / total
+		}
+		result = append(result, countView{Name: name, Count: count, Percent: percent})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func majorMinor(version string) string {
+	if len(version) == 0 {
+		return "Unknown"
+	}
+	parts := strings.SplitN(springboot.SanitizeVersion(version), ".", 3)
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// jdkMajor returns the feature release of the jdk, e.g. 8 for 1.8.0_292 and 17 for 17.0.6
+func jdkMajor(version string) string {
+	if len(version) == 0 {
+		return "Unknown"
+	}
+	parts := strings.Split(springboot.SanitizeVersion(version), ".")
+	if parts[0] == "1" && len(parts) > 1 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+func (o *Output) writeHtml(records any, writer io.Writer) error {
+	result, ok := records.(*ScanResult)
+	if !ok {
+		return fmt.Errorf("html format is not supported for %T", records)
+	}
+	tmpl, err := htmltemplate.New("report.html.tmpl").Funcs(htmltemplate.FuncMap{
+		"lower": strings.ToLower,
+		"time":  formatReportTime,
+	}).ParseFS(templates, "templates/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(writer, newReportView(result))
+}
+
+func (o *Output) writeMarkdown(records any, writer io.Writer) error {
+	result, ok := records.(*ScanResult)
+	if !ok {
+		return fmt.Errorf("markdown format is not supported for %T", records)
+	}
+	tmpl, err := texttemplate.New("report.md.tmpl").Funcs(texttemplate.FuncMap{
+		"cell": markdownCell,
+		"time": formatReportTime,
+	}).ParseFS(templates, "templates/report.md.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(writer, newReportView(result))
+}
+
+// markdownCell keeps the value in one table cell, and stops the markdown viewers from rendering the html in it
+func markdownCell(value any) string {
+	s := strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;").Replace(fmt.Sprint(value))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Report output", func() {
+	var (
+		result  *ScanResult
+		appName = "<script>alert('app')</script>|x"
+		server  = "<img src=x onerror=alert(1)>|host"
+	)
+
+	BeforeEach(func() {
+		result = newTestScanResult()
+		result.Apps[0].AppName = appName
+		// a file logging config gives a finding, which names the app
+		result.Apps[0].LoggingConfigurations = map[string]string{"log4j.properties": "log4j.appender.file=org.apache.log4j.RollingFileAppender"}
+		result.Hosts[1].Server = server
+		result.Errors[0].Server = server
+	})
+
+	render := func(format string, result *ScanResult) string {
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: format}
+		Expect(output.Write(result)).Should(Succeed())
+		return b.String()
+	}
+
+	It("should escape the app and host names in html", func() {
+		html := render("html", result)
+		Expect(html).ShouldNot(ContainSubstring("<script>alert"))
+		Expect(html).ShouldNot(ContainSubstring("<img"))
+		Expect(html).Should(ContainSubstring("&lt;script&gt;alert(&#39;app&#39;)&lt;/script&gt;|x"))
+		Expect(html).Should(ContainSubstring("&lt;img src=x onerror=alert(1)&gt;|host"))
+	})
+
+	It("should escape the app and host names in markdown", func() {
+		markdown := render("markdown", result)
+		Expect(markdown).ShouldNot(ContainSubstring("<script>"))
+		Expect(markdown).ShouldNot(ContainSubstring("<img"))
+		Expect(markdown).Should(ContainSubstring(`| &lt;script&gt;alert('app')&lt;/script&gt;\|x |`))
+		Expect(markdown).Should(ContainSubstring(`| &lt;img src=x onerror=alert(1)&gt;\|host |`))
+	})
+
+	It("should render an empty result", func() {
+		empty := NewScanResult()
+		html := render("html", empty)
+		Expect(html).Should(ContainSubstring("No finding."))
+		Expect(html).Should(ContainSubstring("No error."))
+		Expect(html).Should(ContainSubstring("No app."))
+
+		markdown := render("markdown", empty)
+		Expect(markdown).Should(ContainSubstring("0 apps on 0 hosts, 0 readiness findings, 0 errors."))
+		Expect(markdown).Should(ContainSubstring("No finding."))
+		Expect(strings.TrimSpace(markdown)).Should(HaveSuffix("No error."))
+	})
+})
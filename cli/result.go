@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/Azure/discover-java-apps/springboot"
+	"time"
+)
+
+// ScanSchemaVersion is the version of the json-full document, bump the major version on breaking changes
+const ScanSchemaVersion = "1.0"
+
+// ScanResult is the json-full document, it carries the complete SpringBootApp model and the facts of the scan
+type ScanResult struct {
+	SchemaVersion string                      `json:"schemaVersion"`
+	ToolVersion   string                      `json:"toolVersion"`
+	ScanTime      time.Time                   `json:"scanTime"`
+	Hosts         []*HostFacts                `json:"hosts"`
+	Apps          []*springboot.SpringBootApp `json:"apps"`
+	Errors        []*ScanError                `json:"errors"`
+}
+
+// HostFacts describes a scanned host, the os facts are taken from the apps discovered on it
+type HostFacts struct {
+	Server    string    `json:"server"`
+	Port      int       `json:"port"`
+	OsName    string    `json:"osName,omitempty"`
+	OsVersion string    `json:"osVersion,omitempty"`
+	AppCount  int       `json:"appCount"`
+	StartTime time.Time `json:"startTime"`
+	// DurationMillis is the time spent on the host in milliseconds
+	DurationMillis int64 `json:"durationMillis"`
+	Succeeded      bool  `json:"succeeded"`
+}
+
+type ScanError struct {
+	Server  string `json:"server"`
+	Message string `json:"message"`
+}
+
+func NewScanResult() *ScanResult {
+	return &ScanResult{
+		SchemaVersion: ScanSchemaVersion,
+		ToolVersion:   version,
+		ScanTime:      time.Now().UTC(),
+		Hosts:         []*HostFacts{},
+		Apps:          []*springboot.SpringBootApp{},
+		Errors:        []*ScanError{},
+	}
+}
+
+// AddHost records the outcome of discovering one host
+func (r *ScanResult) AddHost(info springboot.ServerConnectionInfo, start time.Time, apps []*springboot.SpringBootApp, err error) *HostFacts {
+	host := &HostFacts{
+		Server:         info.Server,
+		Port:           info.Port,
+		AppCount:       len(apps),
+		StartTime:      start.UTC(),
+		Succeeded:      err == nil,
+		DurationMillis: time.Since(start).Milliseconds(),
+	}
+	for _, app := range apps {
+		if app != nil && app.Runtime != nil {
+			host.OsName = app.Runtime.OsName
+			host.OsVersion = app.Runtime.OsVersion
+			break
+		}
+	}
+	r.Hosts = append(r.Hosts, host)
+	r.Apps = append(r.Apps, apps...)
+	if err != nil {
+		r.Errors = append(r.Errors, &ScanError{Server: info.Server, Message: err.Error()})
+	}
+	return host
+}
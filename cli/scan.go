@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"os"
+	"strings"
+)
+
+// loadScan reads a result saved by the discover command, the json-full document, the ndjson records or the json array of CliApp,
+// either plain or encrypted by -recipient or -passphrase
+func loadScan(filename string, decryption *decryptionOptions) (*ScanResult, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = decryption.decrypt(b); err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s, %w", filename, err)
+	}
+
+	if isNdjson(b) {
+		result, err := readNdjson(b)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s as ndjson discovery result, %w", filename, err)
+		}
+		return result, nil
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		var result ScanResult
+		if err = json.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("cannot read %s as a discovery result, %w", filename, err)
+		}
+		if major(result.SchemaVersion) != major(ScanSchemaVersion) {
+			return nil, fmt.Errorf("cannot read %s, schema version %s is not supported, expected %s", filename, result.SchemaVersion, ScanSchemaVersion)
+		}
+		// a null app, e.g. of a hand edited result, would fail every consumer keying the apps
+		var apps = make([]*springboot.SpringBootApp, 0, len(result.Apps))
+		for _, app := range result.Apps {
+			if app != nil {
+				apps = append(apps, app)
+			}
+		}
+		result.Apps = apps
+		return &result, nil
+	}
+
+	var cliApps []*CliApp
+	if err = json.Unmarshal(b, &cliApps); err != nil {
+		return nil, fmt.Errorf("cannot read %s as a discovery result, %w", filename, err)
+	}
+	for i := len(cliApps) - 1; i >= 0; i-- {
+		if cliApps[i] == nil {
+			cliApps = append(cliApps[:i], cliApps[i+1:]...)
+		}
+	}
+	// the legacy result has no facts of the scan, the modification time of the file is the best guess of the scan time
+	result := NewScanResult()
+	if info, err := os.Stat(filename); err == nil {
+		result.ScanTime = info.ModTime().UTC()
+	}
+	result.Apps = append(result.Apps, NewCliAppConverter().Convert(cliApps)...)
+	return result, nil
+}
+
+// writeScan writes the complete document for the document formats, or the projection of the apps for the other formats
+func writeScan(output *Output, result *ScanResult) error {
+	result = output.anonymizer.ScanResult(result)
+	switch {
+	case output.document():
+		return output.Write(result)
+	case strings.EqualFold(strings.TrimSpace(output.format), "azure-migrate"):
+		return output.Write(NewAzureMigrateConverter().Convert(result.Apps))
+	}
+	return output.Write(NewSpringBootAppConverter().Convert(result.Apps))
+}
+
+// isNdjson tells the ndjson records from the json-full document, as both start with an object
+func isNdjson(content []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var first map[string]json.RawMessage
+	if err := decoder.Decode(&first); err != nil {
+		return false
+	}
+	_, ok := first["type"]
+	return ok
+}
+
+func major(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
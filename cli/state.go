@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	scanStateFile = "scan.json"
+	hostStateExt  = ".host.json"
+)
+
+// ScanState is the checkpoint of a scan in the state dir, the scan facts in scan.json and a file for every finished host
+type ScanState struct {
+	dir      string
+	scanTime time.Time
+	hosts    map[string]*HostState
+}
+
+// HostState is the outcome of a host, saved as soon as the host is finished
+type HostState struct {
+	Host   *HostFacts                  `json:"host"`
+	Apps   []*springboot.SpringBootApp `json:"apps"`
+	Errors []*ScanError                `json:"errors"`
+}
+
+type scanStateFacts struct {
+	SchemaVersion string    `json:"schemaVersion"`
+	ToolVersion   string    `json:"toolVersion"`
+	ScanTime      time.Time `json:"scanTime"`
+}
+
+// OpenScanState creates the state dir for a new scan, or loads the hosts finished by the interrupted scan to resume.
+// The checkpoint is the plain result, so it cannot be used with the encrypted or anonymized result, which must not be on disk in clear
+func OpenScanState(dir string, resume bool, g *globalOptions) (*ScanState, error) {
+	if len(dir) == 0 {
+		if resume {
+			return nil, errors.New("-resume requires -state-dir")
+		}
+		return nil, nil
+	}
+	if g.encryption.enabled() || g.anonymization.enabled {
+		return nil, errors.New("-state-dir cannot be used with -recipient, -passphrase or -anonymize, the checkpoint is saved in clear")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	state := &ScanState{dir: dir, scanTime: time.Now().UTC(), hosts: make(map[string]*HostState)}
+	if !resume {
+		return state, state.reset()
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, scanStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		// nothing to resume, e.g. the scan was interrupted before the first host finished
+		return state, state.reset()
+	} else if err != nil {
+		return nil, err
+	}
+	var facts scanStateFacts
+	if err = json.Unmarshal(b, &facts); err != nil {
+		return nil, fmt.Errorf("cannot read %s, %w", filepath.Join(dir, scanStateFile), err)
+	}
+	if major(facts.SchemaVersion) != major(ScanSchemaVersion) {
+		return nil, fmt.Errorf("cannot resume the scan of schema version %s, supported version %s", facts.SchemaVersion, ScanSchemaVersion)
+	}
+	state.scanTime = facts.ScanTime
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+hostStateExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var host HostState
+		if err = json.Unmarshal(b, &host); err != nil || host.Host == nil {
+			// the host is scanned again, e.g. the file was truncated when the scan died
+			continue
+		}
+		state.hosts[hostKey(springboot.ServerConnectionInfo{Server: host.Host.Server, Port: host.Host.Port})] = &host
+	}
+	return state, nil
+}
+
+// reset removes the checkpoint of the previous scan and records the facts of the new one
+func (s *ScanState) reset() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+hostStateExt))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return s.write(scanStateFile, &scanStateFacts{SchemaVersion: ScanSchemaVersion, ToolVersion: version, ScanTime: s.scanTime})
+}
+
+// Finished returns the state of the host if it was finished successfully, the failed hosts are scanned again
+func (s *ScanState) Finished(info springboot.ServerConnectionInfo) *HostState {
+	if s == nil {
+		return nil
+	}
+	if host, ok := s.hosts[hostKey(info)]; ok && host.Host.Succeeded {
+		return host
+	}
+	return nil
+}
+
+// Save records the outcome of the host
+func (s *ScanState) Save(info springboot.ServerConnectionInfo, host *HostState) error {
+	if s == nil {
+		return nil
+	}
+	s.hosts[hostKey(info)] = host
+	return s.write(hostKey(info)+hostStateExt, host)
+}
+
+// ScanTime is the start of the first run of the scan, so the resumed result is the same as an uninterrupted one
+func (s *ScanState) ScanTime() time.Time {
+	return s.scanTime
+}
+
+// write replaces the file atomically, so a scan dying in the middle never leaves a truncated checkpoint
+func (s *ScanState) write(name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+// hostKey is the sha256 of server:port, unique per host and safe as a file name whatever the server is, e.g. an IPv6 address
+func hostKey(info springboot.ServerConnectionInfo) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(info.Server) + ":" + strconv.Itoa(info.Port)))
+	return hex.EncodeToString(sum[:])
+}
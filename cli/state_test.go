@@ -0,0 +1,115 @@
+package main
+
+import (
+	"github.com/Azure/discover-java-apps/springboot"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Scan state", func() {
+	var dir string
+	var g globalOptions
+
+	BeforeEach(func() {
+		dir = filepath.Join(GinkgoT().TempDir(), "state")
+		g = globalOptions{}
+	})
+
+	newHostState := func(server string, succeeded bool) *HostState {
+		result := newTestScanResult()
+		host := &HostState{Host: &HostFacts{Server: server, Port: 22, StartTime: testScanTime, Succeeded: succeeded}}
+		if succeeded {
+			host.Apps = result.Apps
+		} else {
+			host.Errors = result.Errors
+		}
+		return host
+	}
+
+	It("should not checkpoint without the state dir", func() {
+		state, err := OpenScanState("", false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(state).Should(BeNil())
+		Expect(state.Save(springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 22}, newHostState("10.0.0.4", true))).Should(Succeed())
+		Expect(state.Finished(springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 22})).Should(BeNil())
+
+		_, err = OpenScanState("", true, &g)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should resume the succeeded hosts and retry the failed ones", func() {
+		state, err := OpenScanState(dir, false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		succeeded := springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 22}
+		failed := springboot.ServerConnectionInfo{Server: "10.0.0.5", Port: 22}
+		Expect(state.Save(succeeded, newHostState("10.0.0.4", true))).Should(Succeed())
+		Expect(state.Save(failed, newHostState("10.0.0.5", false))).Should(Succeed())
+
+		resumed, err := OpenScanState(dir, true, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resumed.ScanTime()).Should(Equal(state.ScanTime()))
+		Expect(resumed.Finished(succeeded)).ShouldNot(BeNil())
+		Expect(resumed.Finished(succeeded).Apps).Should(Equal(newTestScanResult().Apps))
+		Expect(resumed.Finished(failed)).Should(BeNil())
+		Expect(resumed.Finished(springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 2222})).Should(BeNil())
+	})
+
+	It("should start over without -resume", func() {
+		state, err := OpenScanState(dir, false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		info := springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 22}
+		Expect(state.Save(info, newHostState("10.0.0.4", true))).Should(Succeed())
+
+		_, err = OpenScanState(dir, false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		resumed, err := OpenScanState(dir, true, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resumed.Finished(info)).Should(BeNil())
+	})
+
+	It("should rescan the host of a truncated checkpoint", func() {
+		state, err := OpenScanState(dir, false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		info := springboot.ServerConnectionInfo{Server: "10.0.0.4", Port: 22}
+		Expect(state.Save(info, newHostState("10.0.0.4", true))).Should(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, hostKey(info)+hostStateExt), []byte(`{"host":`), 0600)).Should(Succeed())
+
+		resumed, err := OpenScanState(dir, true, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resumed.Finished(info)).Should(BeNil())
+	})
+
+	It("should keep the hosts apart whose names differ only by the unsafe file chars", func() {
+		state, err := OpenScanState(dir, false, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		first := springboot.ServerConnectionInfo{Server: "app:1", Port: 22}
+		second := springboot.ServerConnectionInfo{Server: "app_1", Port: 22}
+		third := springboot.ServerConnectionInfo{Server: "app", Port: 122}
+		Expect(hostKey(first)).ShouldNot(Equal(hostKey(second)))
+		Expect(state.Save(first, newHostState("app:1", true))).Should(Succeed())
+		Expect(state.Save(second, newHostState("app_1", false))).Should(Succeed())
+		Expect(state.Save(third, newHostState("app", false))).Should(Succeed())
+
+		resumed, err := OpenScanState(dir, true, &g)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resumed.Finished(first)).ShouldNot(BeNil())
+		Expect(resumed.Finished(second)).Should(BeNil())
+		Expect(resumed.Finished(third)).Should(BeNil())
+		files, err := filepath.Glob(filepath.Join(dir, "*"+hostStateExt))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).Should(HaveLen(3))
+	})
+
+	DescribeTable("should reject the state dir with the encrypted or anonymized result",
+		func(g globalOptions) {
+			_, err := OpenScanState(dir, false, &g)
+			Expect(err).Should(MatchError(ContainSubstring("-state-dir")))
+			Expect(dir).ShouldNot(BeADirectory())
+		},
+		Entry("recipient", globalOptions{encryption: encryptionOptions{recipients: recipients{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}}}),
+		Entry("passphrase", globalOptions{encryption: encryptionOptions{passphrase: true}}),
+		Entry("anonymize", globalOptions{anonymization: anonymizationOptions{enabled: true}}),
+	)
+})
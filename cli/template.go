@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const bundledTemplateDir = "templates/bundled"
+
+// templateFuncs are the helper functions available in the user-defined templates
+var templateFuncs = template.FuncMap{
+	"join": func(sep string, values any) string {
+		switch v := values.(type) {
+		case []string:
+			return strings.Join(v, sep)
+		case []int:
+			var s []string
+			for _, i := range v {
+				s = append(s, fmt.Sprint(i))
+			}
+			return strings.Join(s, sep)
+		default:
+			return fmt.Sprint(values)
+		}
+	},
+	"humanizeBytes": func(size int64) string {
+		return units.BytesSize(float64(size))
+	},
+	// semverCompare returns -1, 0 or 1, the same as strings.Compare, e.g. {{ if lt (semverCompare .SpringBootVersion "3.0") 0 }}
+	"semverCompare": func(a, b string) int {
+		switch {
+		case springboot.LessThan(a, b):
+			return -1
+		case springboot.GreatThan(a, b):
+			return 1
+		default:
+			return 0
+		}
+	},
+	"toJson": toJson,
+	// toYaml goes through json, so the keys are the same as the json output
+	"toYaml": func(v any) (string, error) {
+		content, err := toJson(v)
+		if err != nil {
+			return "", err
+		}
+		var generic any
+		if err = json.Unmarshal([]byte(content), &generic); err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(generic); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	},
+	"appId":          springboot.AppId,
+	"dependencyName": springboot.DependencyName,
+	"findings":       springboot.AssessReadiness,
+	"lower":          strings.ToLower,
+	"upper":          strings.ToUpper,
+	"csv":            csvField,
+	"md":             markdownCell,
+}
+
+// csvField quotes the value as encoding/csv does, when it has a comma, a quote or a line break
+func csvField(value any) string {
+	s := fmt.Sprint(value)
+	if !strings.ContainsAny(s, ",\"\r\n") && !strings.HasPrefix(s, " ") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func toJson(v any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// loadTemplate parses the template file, or the bundled template with the name when there is no such file
+func loadTemplate(name string) (*template.Template, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no template specified, use -template with a template file or one of the bundled templates: %s", strings.Join(bundledTemplates(), ", "))
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		return template.New(filepath.Base(name)).Funcs(templateFuncs).ParseFiles(name)
+	}
+
+	bundled := path.Join(bundledTemplateDir, name+".tmpl")
+	if _, err := fs.Stat(templates, bundled); err != nil {
+		return nil, fmt.Errorf("template %s is neither a file nor a bundled template, bundled templates: %s", name, strings.Join(bundledTemplates(), ", "))
+	}
+	return template.New(path.Base(bundled)).Funcs(templateFuncs).ParseFS(templates, bundled)
+}
+
+func bundledTemplates() []string {
+	var names []string
+	entries, _ := fs.ReadDir(templates, bundledTemplateDir)
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	return names
+}
+
+func (o *Output) writeTemplate(records any, writer io.Writer) error {
+	tmpl, err := loadTemplate(o.template)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(writer, records)
+}
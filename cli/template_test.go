@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Template output", func() {
+	var (
+		result *ScanResult
+		tricky = "a,b \"c\" |d\ne"
+	)
+
+	BeforeEach(func() {
+		result = newTestScanResult()
+		result.Apps[0].AppName = tricky
+		result.Apps[0].Dependencies = []string{tricky + "-1.0.jar"}
+	})
+
+	It("should escape the csv fields of the bundled template", func() {
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: "template", template: "dependencies.csv"}
+		Expect(output.Write(result)).Should(Succeed())
+
+		records, err := csv.NewReader(&b).ReadAll()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(records).Should(HaveLen(2))
+		Expect(records[1][2]).Should(Equal(tricky))
+		Expect(records[1][4]).Should(Equal(tricky + "-1.0.jar"))
+	})
+
+	It("should escape the markdown cells of the bundled template", func() {
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: "template", template: "inventory.md"}
+		Expect(output.Write(result)).Should(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		row := lines[len(lines)-1]
+		Expect(row).Should(ContainSubstring(`| a,b "c" \|d e |`))
+		Expect(strings.Count(strings.ReplaceAll(row, `\|`, ""), "|")).Should(Equal(10))
+	})
+
+	It("should quote the csv field only when needed", func() {
+		Expect(csvField("plain")).Should(Equal("plain"))
+		Expect(csvField(`a"b`)).Should(Equal(`"a""b"`))
+		Expect(csvField(8080)).Should(Equal("8080"))
+	})
+})
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// version is set at build time by -ldflags "-X main.version=<version>"
+var version = "dev"
+
+const versionUsage = `Usage: discovery version
+
+Print the version
+`
+
+func runVersionCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := newFlagSet("version", versionUsage, stderr)
+	if err := fs.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	fmt.Fprintf(stdout, "discovery %s %s %s/%s\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return 0
+}
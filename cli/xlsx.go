@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Azure/discover-java-apps/springboot"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sheet is a table of the workbook, the rows are linked to the Applications sheet by the AppId column
+type sheet struct {
+	name    string
+	headers []string
+	widths  []float64
+	rows    [][]any
+}
+
+func (s *sheet) add(row ...any) {
+	s.rows = append(s.rows, row)
+}
+
+func (o *Output) writeXlsx(records any, writer io.Writer) error {
+	result, ok := records.(*ScanResult)
+	if !ok {
+		return fmt.Errorf("xlsx format is not supported for %T", records)
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"4472C4"}},
+		Alignment: &excelize.Alignment{Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return err
+	}
+
+	for i, s := range xlsxSheets(result) {
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), s.name)
+		} else {
+			_, err = f.NewSheet(s.name)
+		}
+		if err != nil {
+			return err
+		}
+		if err = writeSheet(f, s, headerStyle, dateStyle); err != nil {
+			return fmt.Errorf("failed to write sheet %s, %w", s.name, err)
+		}
+	}
+	f.SetActiveSheet(0)
+	return f.Write(writer)
+}
+
+func writeSheet(f *excelize.File, s sheet, headerStyle int, dateStyle int) error {
+	if err := f.SetSheetRow(s.name, "A1", &s.headers); err != nil {
+		return err
+	}
+	for i, row := range s.rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err = f.SetSheetRow(s.name, cell, &row); err != nil {
+			return err
+		}
+		for j, value := range row {
+			if _, ok := value.(time.Time); ok {
+				cell, _ = excelize.CoordinatesToCellName(j+1, i+2)
+				if err = f.SetCellStyle(s.name, cell, cell, dateStyle); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	lastCol, err := excelize.ColumnNumberToName(len(s.headers))
+	if err != nil {
+		return err
+	}
+	if err = f.SetCellStyle(s.name, "A1", lastCol+"1", headerStyle); err != nil {
+		return err
+	}
+	for i, width := range s.widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err = f.SetColWidth(s.name, col, col, width); err != nil {
+			return err
+		}
+	}
+	if err = f.SetPanes(s.name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	// a table needs at least one row besides the header, the empty sheet gets a filter only
+	if len(s.rows) == 0 {
+		return f.AutoFilter(s.name, "A1:"+lastCol+"1", nil)
+	}
+	return f.AddTable(s.name, &excelize.Table{
+		Range:          fmt.Sprintf("A1:%s%d", lastCol, len(s.rows)+1),
+		Name:           s.name,
+		StyleName:      "TableStyleMedium2",
+		ShowRowStripes: &[]bool{true}[0],
+	})
+}
+
+func xlsxSheets(result *ScanResult) []sheet {
+	apps := sheet{
+		name: "Applications",
+		headers: []string{"AppId", "Server", "AppName", "AppType", "SpringBootVersion", "BuildJdkVersion", "MavenArtifactGroup",
+			"MavenArtifact", "MavenArtifactVersion", "JarFileLocation", "JarFileSize(KB)", "Checksum", "JarFileModifiedTime",
+			"Dependencies", "ApplicationConfigurations", "LoggingConfigurations", "Certificates"},
+		widths: []float64{14, 20, 24, 18, 18, 16, 24, 24, 20, 50, 16, 66, 22, 14, 26, 22, 14},
+	}
+	runtimes := sheet{
+		name: "Runtimes",
+		headers: []string{"AppId", "Server", "Pid", "Uid", "RuntimeJdkVersion", "JvmHeapMemory(MB)", "OsName", "OsVersion",
+			"AppPort", "JavaCmd", "JvmOptions"},
+		widths: []float64{14, 20, 10, 10, 18, 20, 14, 12, 10, 40, 80},
+	}
+	dependencies := sheet{
+		name:    "Dependencies",
+		headers: []string{"AppId", "Dependency"},
+		widths:  []float64{14, 60},
+	}
+	configurations := sheet{
+		name:    "Configurations",
+		headers: []string{"AppId", "Type", "Name", "Content"},
+		widths:  []float64{14, 16, 40, 100},
+	}
+	ports := sheet{
+		name:    "Ports",
+		headers: []string{"AppId", "Server", "Port", "AppPort"},
+		widths:  []float64{14, 20, 10, 10},
+	}
+	errors := sheet{
+		name:    "Errors",
+		headers: []string{"Server", "Message"},
+		widths:  []float64{20, 120},
+	}
+
+	for _, app := range result.Apps {
+		if app == nil {
+			continue
+		}
+		id := springboot.AppId(app)
+		var artifact = &springboot.Artifact{}
+		if app.Artifact != nil {
+			artifact = app.Artifact
+		}
+		var runtime = &springboot.Runtime{}
+		if app.Runtime != nil {
+			runtime = app.Runtime
+		}
+
+		apps.add(id, runtime.Server, app.AppName, string(app.AppType), app.SpringBootVersion, app.BuildJdkVersion,
+			artifact.Group, artifact.Name, artifact.Version, app.JarFileLocation, app.JarSize/springboot.KiB, app.Checksum,
+			cellTime(app.LastModifiedTime), len(app.Dependencies), len(app.ApplicationConfigurations),
+			len(app.LoggingConfigurations), len(app.Certificates))
+
+		runtimes.add(id, runtime.Server, runtime.Pid, runtime.Uid, runtime.RuntimeJdkVersion, runtime.JvmMemory/springboot.MiB,
+			runtime.OsName, runtime.OsVersion, runtime.AppPort, runtime.JavaCmd, strings.Join(runtime.JvmOptions, " "))
+
+		for _, dep := range app.Dependencies {
+			dependencies.add(id, dep)
+		}
+
+		for _, name := range sortedNames(app.ApplicationConfigurations) {
+			configurations.add(id, "Application", name, app.ApplicationConfigurations[name])
+		}
+		for _, name := range sortedNames(app.LoggingConfigurations) {
+			configurations.add(id, "Logging", name, app.LoggingConfigurations[name])
+		}
+		for _, cert := range app.Certificates {
+			configurations.add(id, "Certificate", cert, "")
+		}
+		for _, env := range runtime.Environments {
+			name, value, _ := strings.Cut(env, "=")
+			configurations.add(id, "Environment", name, value)
+		}
+
+		var bindingPorts = append([]int(nil), runtime.BindingPorts...)
+		if runtime.AppPort > 0 && !containsPort(bindingPorts, runtime.AppPort) {
+			bindingPorts = append(bindingPorts, runtime.AppPort)
+		}
+		sort.Ints(bindingPorts)
+		for _, port := range bindingPorts {
+			ports.add(id, runtime.Server, port, port == runtime.AppPort)
+		}
+	}
+
+	for _, e := range result.Errors {
+		errors.add(e.Server, e.Message)
+	}
+
+	return []sheet{apps, runtimes, dependencies, configurations, ports, errors}
+}
+
+// cellTime leaves the cell empty for the unknown time, excelize writes time.Time as a date cell
+func cellTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+func sortedNames(m map[string]string) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
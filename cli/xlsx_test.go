@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"github.com/Azure/discover-java-apps/springboot"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/xuri/excelize/v2"
+)
+
+var _ = Describe("Xlsx output", func() {
+	It("should write the sheets linked by the app id", func() {
+		var b bytes.Buffer
+		output := &Output{writer: &b, format: "xlsx"}
+		result := newTestScanResult()
+		Expect(output.Write(result)).Should(Succeed())
+
+		f, err := excelize.OpenReader(&b)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer f.Close()
+		Expect(f.GetSheetList()).Should(Equal([]string{"Applications", "Runtimes", "Dependencies", "Configurations", "Ports", "Errors"}))
+
+		rows, err := f.GetRows("Applications")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows).Should(HaveLen(2))
+		Expect(rows[0]).Should(Equal([]string{"AppId", "Server", "AppName", "AppType", "SpringBootVersion", "BuildJdkVersion", "MavenArtifactGroup",
+			"MavenArtifact", "MavenArtifactVersion", "JarFileLocation", "JarFileSize(KB)", "Checksum", "JarFileModifiedTime",
+			"Dependencies", "ApplicationConfigurations", "LoggingConfigurations", "Certificates"}))
+		id := springboot.AppId(result.Apps[0])
+		Expect(rows[1][:3]).Should(Equal([]string{id, "10.0.0.4", "hellospring"}))
+
+		rows, err = f.GetRows("Runtimes")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows[0]).Should(Equal([]string{"AppId", "Server", "Pid", "Uid", "RuntimeJdkVersion", "JvmHeapMemory(MB)", "OsName", "OsVersion",
+			"AppPort", "JavaCmd", "JvmOptions"}))
+		Expect(rows[1][5]).Should(Equal("512"))
+
+		rows, err = f.GetRows("Dependencies")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows).Should(Equal([][]string{{"AppId", "Dependency"}, {id, "spring-boot-2.7.5.jar"}, {id, "postgresql-42.5.0.jar"}}))
+
+		rows, err = f.GetRows("Configurations")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows[0]).Should(Equal([]string{"AppId", "Type", "Name", "Content"}))
+		Expect(rows).Should(HaveLen(4))
+
+		rows, err = f.GetRows("Ports")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows).Should(Equal([][]string{{"AppId", "Server", "Port", "AppPort"}, {id, "10.0.0.4", "8080", "TRUE"}, {id, "10.0.0.4", "8081", "FALSE"}}))
+
+		rows, err = f.GetRows("Errors")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(rows).Should(Equal([][]string{{"Server", "Message"}, {"10.0.0.5", "failed to connect to target server"}}))
+	})
+
+	It("should reject the apps without the scan", func() {
+		output := &Output{writer: &bytes.Buffer{}, format: "xlsx"}
+		Expect(output.Write([]*CliApp{})).ShouldNot(Succeed())
+	})
+})
@@ -0,0 +1,84 @@
+package springboot
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord is a command run on a server, as sent to the server, the output itself is never recorded
+type AuditRecord struct {
+	Time        time.Time        `json:"time"`
+	Host        string           `json:"host"`
+	User        string           `json:"user"`
+	Command     string           `json:"command"`
+	Escalation  EscalationMethod `json:"escalation,omitempty"`
+	ExitStatus  int              `json:"exitStatus"`
+	DurationMs  int64            `json:"durationMs"`
+	OutputBytes int              `json:"outputBytes"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Audit writes the records as JSON lines, one audit is shared by the connections of all the hosts
+type Audit struct {
+	mux     sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func NewAudit(w io.Writer) *Audit {
+	return &Audit{encoder: json.NewEncoder(w)}
+}
+
+// WithAudit records every command run on the server, the rejected ones as well
+func WithAudit(audit *Audit) SshOption {
+	return func(s *linuxServer) {
+		s.audit = audit
+	}
+}
+
+// Err is the first error of writing the records, the commands are run even when the audit cannot be written
+func (a *Audit) Err() error {
+	if a == nil {
+		return nil
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return a.err
+}
+
+func (a *Audit) record(r AuditRecord) {
+	if a == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if err := a.encoder.Encode(r); err != nil && a.err == nil {
+		a.err = err
+	}
+}
+
+// exitStatus is 0 for the success, -1 when the command did not exit, e.g. the connection dropped
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitError *ssh.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitStatus()
+	}
+	return -1
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,183 @@
+package springboot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// argPatterns match the args of the templates, %s is a word quoted by shellQuote, or a plain word without the shell syntax
+var argPatterns = map[byte]string{
+	'd': `-?\d+`,
+	's': `(?:'(?:[^']|'\\'')*'|[^\s'"\\;&|$` + "`" + `<>(){}*?]+)`,
+}
+
+// CommandAllowlist rejects the commands not built from its templates
+type CommandAllowlist struct {
+	templates []string
+	patterns  []*regexp.Regexp
+}
+
+// DefaultCommandAllowlist allows the commands of LinuxCommands only
+func DefaultCommandAllowlist() *CommandAllowlist {
+	allowlist, err := NewCommandAllowlist()
+	if err != nil {
+		panic(err)
+	}
+	for _, command := range LinuxCommands {
+		if err = allowlist.Add(command.Template); err != nil {
+			panic(err)
+		}
+	}
+	return allowlist
+}
+
+// NewCommandAllowlist allows the commands built from the templates, which have the placeholders of fmt, %d, %s or %[1]d
+func NewCommandAllowlist(templates ...string) (*CommandAllowlist, error) {
+	allowlist := &CommandAllowlist{}
+	for _, template := range templates {
+		if err := allowlist.Add(template); err != nil {
+			return nil, err
+		}
+	}
+	return allowlist, nil
+}
+
+// ReadCommandAllowlist reads a template or a name of LinuxCommands per line, the empty lines and the lines starting with # are skipped
+func ReadCommandAllowlist(r io.Reader) (*CommandAllowlist, error) {
+	allowlist := &CommandAllowlist{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		template := line
+		for _, command := range LinuxCommands {
+			if command.Name == line {
+				template = command.Template
+				break
+			}
+		}
+		if err := allowlist.Add(template); err != nil {
+			return nil, err
+		}
+	}
+	return allowlist, scanner.Err()
+}
+
+// Add allows the commands built from the template
+func (a *CommandAllowlist) Add(template string) error {
+	var b strings.Builder
+	b.WriteString("^")
+	err := parseTemplate(template, func(literal string) {
+		b.WriteString(regexp.QuoteMeta(literal))
+	}, func(verb byte, _ int) {
+		b.WriteString(argPatterns[verb])
+	})
+	if err != nil {
+		return err
+	}
+	b.WriteString("$")
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return fmt.Errorf("invalid command template %s, %w", template, err)
+	}
+	a.templates = append(a.templates, template)
+	a.patterns = append(a.patterns, pattern)
+	return nil
+}
+
+// Allowed tells whether the command is built from one of the templates
+func (a *CommandAllowlist) Allowed(cmd string) bool {
+	for _, pattern := range a.patterns {
+		if pattern.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// Templates are the templates allowed, in the order added
+func (a *CommandAllowlist) Templates() []string {
+	return append([]string(nil), a.templates...)
+}
+
+// WithCommandAllowlist rejects the commands not on the allowlist before they are sent to the server
+func WithCommandAllowlist(allowlist *CommandAllowlist) SshOption {
+	return func(s *linuxServer) {
+		s.allowlist = allowlist
+	}
+}
+
+// allow rejects the command not on the allowlist, all commands are allowed without an allowlist
+func (s *linuxServer) allow(cmd string) error {
+	if s.allowlist == nil || s.allowlist.Allowed(cmd) {
+		return nil
+	}
+	err := CommandRejected{error: fmt.Errorf("command is not on the allowlist"), message: cmd}
+	GetAzureLogger(s.ctx).Warning(err, "Command rejected", "cmd", cmd, "host", s.server)
+	s.audit.record(AuditRecord{Host: s.server, User: s.username, Command: cmd, ExitStatus: -1, Error: err.Error()})
+	return err
+}
+
+// RenderCommand renders the template with the names of the args as the placeholders, e.g. cat /proc/<pid>/environ
+func RenderCommand(command LinuxCommand) string {
+	var b strings.Builder
+	_ = parseTemplate(command.Template, func(literal string) {
+		b.WriteString(literal)
+	}, func(verb byte, index int) {
+		name := "arg" + strconv.Itoa(index+1)
+		if index < len(command.Args) {
+			name = command.Args[index]
+		}
+		b.WriteString("<" + name + ">")
+	})
+	return b.String()
+}
+
+// parseTemplate splits the fmt template into the literals and the verbs, the explicit index like %[1]d is supported
+func parseTemplate(template string, literal func(string), verb func(verb byte, index int)) error {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			b.WriteByte(template[i])
+			continue
+		}
+		i++
+		if i < len(template) && template[i] == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		index := next
+		if i < len(template) && template[i] == '[' {
+			end := strings.IndexByte(template[i:], ']')
+			if end < 0 {
+				return fmt.Errorf("invalid command template %s, unclosed index", template)
+			}
+			n, err := strconv.Atoi(template[i+1 : i+end])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid command template %s, bad index", template)
+			}
+			index = n - 1
+			i += end + 1
+		}
+		if i >= len(template) || (template[i] != 'd' && template[i] != 's') {
+			return fmt.Errorf("invalid command template %s, only %%d and %%s are supported", template)
+		}
+		if b.Len() > 0 {
+			literal(b.String())
+			b.Reset()
+		}
+		verb(template[i], index)
+		next = index + 1
+	}
+	if b.Len() > 0 {
+		literal(b.String())
+	}
+	return nil
+}
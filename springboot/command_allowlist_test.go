@@ -0,0 +1,84 @@
+package springboot
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Command allowlist", func() {
+	jar := "/opt/it's app.jar"
+
+	It("should allow the commands of the discovery by default", func() {
+		allowlist := DefaultCommandAllowlist()
+		for _, cmd := range []string{
+			GetProcessScanCmd(),
+			GetProcStatusCmd(1234),
+			GetFdLinksCmd(1234),
+			GetCwdCmd(1234),
+			GetReadArgFileCmd("/opt/my app/jvm.options"),
+			GetReadArgFileAsOwnerCmd(1000, "/opt/it's app/jvm.options"),
+			GetSha256Cmd("/opt/app/app-1.0.jar"),
+			GetSha256Cmd("/opt/it's app.jar"),
+			GetStatCmd(jar),
+			GetReadRangeCmd(jar, 0, 1048576),
+			GetUnzipCheckCmd(),
+			GetUnzipListCmd("/opt/it's app.jar"),
+			GetUnzipPipeCmd("/opt/it's app.jar", "BOOT-INF/classes/application*.yml"),
+			GetEnvCmd(1234),
+			GetJdkVersionCmd("/usr/lib/jvm/java-17/bin/java"),
+			GetTotalMemoryCmd(),
+			GetDefaultMaxHeap("/usr/bin/java"),
+			GetNetTcpCmd(1234),
+			GetNetTcp6Cmd(1234),
+			GetOsReleaseCmd(),
+			GetCentOsReleaseCmd(),
+		} {
+			Expect(allowlist.Allowed(cmd)).Should(BeTrue(), "%s", cmd)
+		}
+	})
+
+	It("should reject the commands not built from the templates", func() {
+		allowlist := DefaultCommandAllowlist()
+		for _, cmd := range []string{
+			"rm -rf /",
+			"id",
+			fmt.Sprintf(LinuxSha256Cmd, "/opt/app.jar; rm -rf /"),
+			fmt.Sprintf(LinuxSha256Cmd, "$(id)"),
+			fmt.Sprintf(LinuxStatCmd, "'a' ; id"),
+			GetEnvCmd(1) + "; id",
+			fmt.Sprintf(LinuxGetJdkVersionCmd, "`id`"),
+			GetEnvCmd(1) + " > /tmp/environ",
+		} {
+			Expect(allowlist.Allowed(cmd)).Should(BeFalse(), "%s", cmd)
+		}
+	})
+
+	It("should read the names and the templates", func() {
+		allowlist, err := ReadCommandAllowlist(strings.NewReader("# only the process scan and environ\nprocess-scan\n\n cat /proc/%d/environ \n"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(allowlist.Templates()).Should(Equal([]string{LinuxProcessScanCmd, LinuxGetEnvCmd}))
+		Expect(allowlist.Allowed(GetProcessScanCmd())).Should(BeTrue())
+		Expect(allowlist.Allowed(GetEnvCmd(1))).Should(BeTrue())
+		Expect(allowlist.Allowed(GetTotalMemoryCmd())).Should(BeFalse())
+
+		_, err = ReadCommandAllowlist(strings.NewReader("cat %x"))
+		Expect(err).Should(HaveOccurred())
+		_, err = NewCommandAllowlist("cat /proc/%[0]d/environ")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should render the templates with the names of the args", func() {
+		rendered := map[string]string{}
+		for _, command := range LinuxCommands {
+			rendered[command.Name] = RenderCommand(command)
+		}
+		Expect(rendered["fd-links"]).Should(Equal("ls -l /proc/<pid>/fd"))
+		Expect(rendered["stat"]).Should(Equal("stat -c '%s %Y' <jar file>"))
+		Expect(rendered["jdk-version"]).Should(Equal("<java> -version 2>&1"))
+		Expect(rendered["jdk-release"]).Should(Equal("cat <java home>/release"))
+		Expect(rendered["process-scan"]).Should(Equal(LinuxProcessScanCmd))
+	})
+})
@@ -1,7 +1,9 @@
 package springboot
 
 import (
+	"bytes"
 	_ "embed"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
 )
@@ -9,28 +11,91 @@ import (
 //go:embed config.yml
 var defaultConfigYaml string
 
+// ConfigSchema is the JSON Schema of YamlConfig, which can be used by editors to validate a custom config file
+//
+//go:embed config.schema.json
+var ConfigSchema string
+
 var YamlCfg = NewYamlConfigOrDie(defaultConfigYaml)
 
+// YamlCfgWarnings are the env overrides ignored when loading YamlCfg, they are kept apart since YamlConfig is generated by yaml2go
+var YamlCfgWarnings []ConfigError
+
 var ConfigPathEnvKey = "CONFIG_PATH"
 
+// NewYamlConfigOrDie overlays the file from CONFIG_PATH and the DISCOVERY_ prefixed env on top of the given default config,
+// the ignored env overrides are kept in YamlCfgWarnings
 func NewYamlConfigOrDie(defaultConfigYaml string) YamlConfig {
+	yg, warnings, err := loadConfig(defaultConfigYaml, configFilesFromEnv(), os.Environ())
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config, %s", err.Error()))
+	}
+	YamlCfgWarnings = warnings
+	return yg
+}
+
+// LoadConfig overlays the file from CONFIG_PATH and then the given files on top of the default config,
+// the DISCOVERY_ prefixed env overrides are applied last, the ones ignored are returned as the warnings
+func LoadConfig(files ...string) (YamlConfig, []ConfigError, error) {
+	return loadConfig(defaultConfigYaml, append(configFilesFromEnv(), files...), os.Environ())
+}
+
+// UseConfig replaces the config used by discovery, as well as the patterns compiled from it
+func UseConfig(cfg YamlConfig, warnings []ConfigError) {
+	YamlCfg = cfg
+	YamlCfgWarnings = warnings
+	Patterns = newPatterns()
+}
+
+func configFilesFromEnv() []string {
+	if cfgFile := os.Getenv(ConfigPathEnvKey); len(cfgFile) > 0 {
+		return []string{cfgFile}
+	}
+	return nil
+}
+
+func loadConfig(defaultConfigYaml string, files []string, environ []string) (YamlConfig, []ConfigError, error) {
 	yg := YamlConfig{}
-	var err error
-	var b []byte
-	if len(os.Getenv(ConfigPathEnvKey)) > 0 {
-		cfgFile := os.Getenv(ConfigPathEnvKey)
-		b, err = os.ReadFile(cfgFile)
+	layers := []configLayer{{source: "default config", content: []byte(defaultConfigYaml)}}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
 		if err != nil {
-			panic(err)
+			return yg, nil, err
 		}
-		err = yaml.Unmarshal(b, &yg)
-	} else {
-		err = yaml.Unmarshal([]byte(defaultConfigYaml), &yg)
+		layers = append(layers, configLayer{source: file, content: b})
 	}
 
+	root, warnings, err := mergeConfigLayers(layers, environ)
 	if err != nil {
-		panic(err)
+		return yg, nil, err
 	}
 
-	return yg
+	if err = root.Decode(&yg); err != nil {
+		return yg, nil, err
+	}
+	return yg, warnings, nil
+}
+
+// NewYamlConfig validates the content and unmarshal it into YamlConfig
+func NewYamlConfig(content []byte) (YamlConfig, error) {
+	yg := YamlConfig{}
+	if errs := ValidateConfig(content); len(errs) > 0 {
+		return yg, configErrors("config", errs)
+	}
+
+	if err := yaml.Unmarshal(content, &yg); err != nil {
+		return yg, err
+	}
+	return yg, nil
+}
+
+// DumpConfig marshals the config back into yaml
+func DumpConfig(cfg YamlConfig) (string, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return "", err
+	}
+	return b.String(), nil
 }
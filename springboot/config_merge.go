@@ -0,0 +1,249 @@
+package springboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+const (
+	// AppendTag marks a list in a config file to be appended to the list from the lower layer, e.g. cert: !append [".crt"]
+	AppendTag = "!append"
+	// ReplaceTag marks a list in a config file to replace the list from the lower layer, which is the default for plain lists
+	ReplaceTag = "!replace"
+
+	ConfigEnvPrefix = "DISCOVERY_"
+)
+
+type configLayer struct {
+	source  string
+	content []byte
+}
+
+// configSchema is the part of the JSON Schema of YamlConfig needed to resolve the env overrides
+type configSchema struct {
+	Type        string                   `json:"type"`
+	Ref         string                   `json:"$ref"`
+	Properties  map[string]*configSchema `json:"properties"`
+	Definitions map[string]*configSchema `json:"definitions"`
+}
+
+// mergeConfigLayers overlays the layers in order, later layer wins, then applies the env overrides on the result,
+// the env overrides which cannot be applied are returned as warnings
+func mergeConfigLayers(layers []configLayer, environ []string) (*yaml.Node, []ConfigError, error) {
+	var root *yaml.Node
+	for _, layer := range layers {
+		if errs := ValidateConfig(layer.content); len(errs) > 0 {
+			return nil, nil, configErrors(layer.source, errs)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(layer.content, &doc); err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("malformed yaml in %s", layer.source))
+		}
+		if len(doc.Content) == 0 {
+			// empty file, nothing to overlay
+			continue
+		}
+		root = mergeNode(root, doc.Content[0])
+	}
+
+	if root == nil {
+		root = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	warnings, err := applyEnvOverrides(root, environ)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if errs := validateConfigNode(root); len(errs) > 0 {
+		return nil, nil, configErrors("merged config", errs)
+	}
+	return root, warnings, nil
+}
+
+func mergeNode(base *yaml.Node, overlay *yaml.Node) *yaml.Node {
+	if base == nil {
+		return stripListTag(overlay)
+	}
+
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			if idx := mappingIndex(base, key.Value); idx >= 0 {
+				base.Content[idx+1] = mergeNode(base.Content[idx+1], value)
+			} else {
+				base.Content = append(base.Content, key, stripListTag(value))
+			}
+		}
+		return base
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && overlay.Tag == AppendTag:
+		for _, item := range overlay.Content {
+			if !containsScalar(base, item) {
+				base.Content = append(base.Content, item)
+			}
+		}
+		return base
+	default:
+		return stripListTag(overlay)
+	}
+}
+
+// stripListTag removes the merge markers, so the node can be decoded as a plain list
+func stripListTag(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.SequenceNode && (node.Tag == AppendTag || node.Tag == ReplaceTag) {
+		node.Tag = "!!seq"
+	}
+	return node
+}
+
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsScalar(seq *yaml.Node, item *yaml.Node) bool {
+	if item.Kind != yaml.ScalarNode {
+		return false
+	}
+	for _, existing := range seq.Content {
+		if existing.Kind == yaml.ScalarNode && existing.Value == item.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEnvOverrides sets the values from DISCOVERY_ prefixed env, e.g. DISCOVERY_SERVER_CONNECT_PARALLELISM=10,
+// lists are given as comma separated values and replace the list from config files.
+// The keys are resolved by the schema, so a key absent from the config files can be set as well,
+// the env which is not a key of the schema is skipped with a warning, it may be set for another purpose,
+// and so is the env of an invalid value, so a bad env never fails the commands not even loading the config, e.g. version
+func applyEnvOverrides(root *yaml.Node, environ []string) ([]ConfigError, error) {
+	var schema configSchema
+	if err := json.Unmarshal([]byte(ConfigSchema), &schema); err != nil {
+		return nil, errors.Wrap(err, "malformed config schema")
+	}
+
+	var warnings []ConfigError
+	for _, env := range environ {
+		if !strings.HasPrefix(env, ConfigEnvPrefix) {
+			continue
+		}
+		idx := strings.Index(env, "=")
+		if idx < 0 {
+			continue
+		}
+		name, value := env[:idx], env[idx+1:]
+		path, leaf := lookupEnvSchema(&schema, &schema, strings.ToLower(strings.TrimPrefix(name, ConfigEnvPrefix)))
+		if leaf == nil {
+			warnings = append(warnings, ConfigError{error: errors.New("no such config key, ignored"), Path: name, Value: value})
+			continue
+		}
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		switch leaf.Type {
+		case "array":
+			node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+				}
+			}
+		case "object":
+			warnings = append(warnings, ConfigError{error: errors.New("only a value or a list can be overridden by env, ignored"), Path: name, Value: value})
+			continue
+		}
+		undo := setNode(root, path, node)
+		if errs := validateConfigNode(root); len(errs) > 0 {
+			undo()
+			warnings = append(warnings, ConfigError{error: errors.Wrap(errs[0], "invalid value, ignored"), Path: name, Value: value})
+		}
+	}
+	return warnings, nil
+}
+
+// lookupEnvSchema resolves the lower-cased env key against the properties of the schema,
+// keys are joined by underscore and may contain underscores themselves, e.g. pattern_logging_file_patterns
+func lookupEnvSchema(root *configSchema, schema *configSchema, key string) ([]string, *configSchema) {
+	schema = resolveSchemaRef(root, schema)
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	// the longer name first, so the resolution does not depend on the order of the map
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j]) || len(names[i]) == len(names[j]) && names[i] < names[j]
+	})
+	for _, name := range names {
+		k := strings.ToLower(name)
+		if key == k {
+			return []string{name}, resolveSchemaRef(root, schema.Properties[name])
+		}
+		if strings.HasPrefix(key, k+"_") {
+			if path, leaf := lookupEnvSchema(root, schema.Properties[name], key[len(k)+1:]); leaf != nil {
+				return append([]string{name}, path...), leaf
+			}
+		}
+	}
+	return nil, nil
+}
+
+func resolveSchemaRef(root *configSchema, schema *configSchema) *configSchema {
+	if definition, ok := root.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]; ok && len(schema.Ref) > 0 {
+		return definition
+	}
+	return schema
+}
+
+// setNode replaces the value at the path, the missing mappings on the path are created,
+// the returned undo restores the mapping changed first, which holds all the changes below it
+func setNode(root *yaml.Node, path []string, value *yaml.Node) (undo func()) {
+	if len(path) == 0 {
+		return func() {}
+	}
+	node := root
+	for i, key := range path {
+		idx := mappingIndex(node, key)
+		if undo == nil && (i == len(path)-1 || idx < 0 || node.Content[idx+1].Kind != yaml.MappingNode) {
+			changed, content := node, append([]*yaml.Node(nil), node.Content...)
+			undo = func() {
+				changed.Content = content
+			}
+		}
+		if i == len(path)-1 {
+			if idx >= 0 {
+				node.Content[idx+1] = value
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+			}
+			return undo
+		}
+		if idx < 0 || node.Content[idx+1].Kind != yaml.MappingNode {
+			child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if idx >= 0 {
+				node.Content[idx+1] = child
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+			}
+		}
+		node = node.Content[mappingIndex(node, key)+1]
+	}
+	return undo
+}
+
+func configErrors(source string, errs []ConfigError) error {
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return fmt.Errorf("%d invalid config entries found in %s:\n%s", len(errs), source, strings.Join(messages, "\n"))
+}
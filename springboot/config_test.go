@@ -60,3 +60,203 @@ var _ = Describe("test config loader", func() {
 		})
 	})
 })
+
+var _ = Describe("test config validation", func() {
+
+	When("config is valid", func() {
+		It("should report nothing for the default config", func() {
+			Expect(ValidateConfig([]byte(defaultConfigYaml))).Should(BeEmpty())
+		})
+	})
+
+	When("config has invalid regex and yamlpath", func() {
+		var content = `
+server:
+  connect:
+    parallel: true
+    parallelism: 0
+pattern:
+  app:
+    - "application\\.ya?ml"
+    - "application-(\\w+\\.ya?ml"
+  logging:
+    file_patterns:
+      - "log4j[\\.xml"
+    console_output:
+      yamlpath:
+        - "$[*"
+`
+		It("should report every invalid entry with its location", func() {
+			errs := ValidateConfig([]byte(content))
+			Expect(errs).Should(HaveLen(4))
+			Expect(errs[0].Path).Should(Equal("pattern.app[1]"))
+			Expect(errs[0].Line).Should(Equal(9))
+			Expect(errs[1].Path).Should(Equal("pattern.logging.file_patterns[0]"))
+			Expect(errs[1].Line).Should(Equal(12))
+			Expect(errs[2].Path).Should(Equal("pattern.logging.console_output.yamlpath[0]"))
+			Expect(errs[2].Line).Should(Equal(15))
+			Expect(errs[3].Path).Should(Equal("server.connect.parallelism"))
+			Expect(IsConfigError(errs[0])).Should(BeTrue())
+		})
+
+		It("should fail to create config", func() {
+			Expect(NewYamlConfig([]byte(content))).Error().Should(HaveOccurred())
+		})
+	})
+
+	When("config is not a valid yaml", func() {
+		It("should report malformed yaml", func() {
+			Expect(ValidateConfig([]byte("pattern: [abc"))).Should(HaveLen(1))
+		})
+	})
+
+	When("config has wrong types", func() {
+		It("should report type errors", func() {
+			Expect(ValidateConfig([]byte("server:\n  connect:\n    parallelism: abc\n"))).Should(HaveLen(1))
+		})
+	})
+})
+
+var _ = Describe("test layered config", func() {
+	var (
+		dir      string
+		defaults = `
+server:
+  connect:
+    parallel: true
+    parallelism: 50
+pattern:
+  cert:
+    - ".pem"
+    - ".jks"
+  static:
+    folder:
+      - "/static/"
+  logging:
+    file_patterns:
+      - "logback\\.xml"
+env:
+  denylist:
+    - "PATH"
+`
+	)
+
+	writeFile := func(name, content string) string {
+		f := filepath.Join(dir, name)
+		Expect(os.WriteFile(f, []byte(content), 0600)).Should(Succeed())
+		return f
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	When("user file has no markers", func() {
+		It("should override values and replace lists, keep the others from defaults", func() {
+			f := writeFile("user.yml", "server:\n  connect:\n    parallelism: 10\npattern:\n  cert:\n    - \".crt\"\n")
+			cfg, _, err := loadConfig(defaults, []string{f}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cfg.Server.Connect.Parallel).Should(BeTrue())
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(10))
+			Expect(cfg.Pattern.Cert).Should(Equal([]string{".crt"}))
+			Expect(cfg.Pattern.Static.Folder).Should(Equal([]string{"/static/"}))
+			Expect(cfg.Env.Denylist).Should(Equal([]string{"PATH"}))
+		})
+	})
+
+	When("user file has append and replace markers", func() {
+		It("should append or replace the lists accordingly", func() {
+			f := writeFile("user.yml", "pattern:\n  cert: !append\n    - \".crt\"\n    - \".pem\"\n  static:\n    folder: !replace\n      - \"/public/\"\n")
+			cfg, _, err := loadConfig(defaults, []string{f}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cfg.Pattern.Cert).Should(Equal([]string{".pem", ".jks", ".crt"}))
+			Expect(cfg.Pattern.Static.Folder).Should(Equal([]string{"/public/"}))
+		})
+	})
+
+	When("multiple user files are given", func() {
+		It("should overlay them in order", func() {
+			first := writeFile("first.yml", "pattern:\n  cert: !append [\".crt\"]\n")
+			second := writeFile("second.yml", "pattern:\n  cert: !append [\".p7b\"]\nenv:\n  denylist: !append [\"HOME\"]\n")
+			cfg, _, err := loadConfig(defaults, []string{first, second}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cfg.Pattern.Cert).Should(Equal([]string{".pem", ".jks", ".crt", ".p7b"}))
+			Expect(cfg.Env.Denylist).Should(Equal([]string{"PATH", "HOME"}))
+		})
+	})
+
+	When("env overrides are given", func() {
+		It("should apply them after the files", func() {
+			f := writeFile("user.yml", "server:\n  connect:\n    parallelism: 10\n")
+			cfg, _, err := loadConfig(defaults, []string{f}, []string{
+				"DISCOVERY_SERVER_CONNECT_PARALLELISM=5",
+				"DISCOVERY_SERVER_CONNECT_PARALLEL=false",
+				"DISCOVERY_PATTERN_LOGGING_FILE_PATTERNS=log4j2\\.xml, logback\\.xml",
+				"OTHER_ENV=1",
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(5))
+			Expect(cfg.Server.Connect.Parallel).Should(BeFalse())
+			Expect(cfg.Pattern.Logging.FilePatterns).Should(Equal([]string{"log4j2\\.xml", "logback\\.xml"}))
+		})
+
+		It("should skip unknown keys with a warning", func() {
+			cfg, warnings, err := loadConfig(defaults, nil, []string{"DISCOVERY_SERVER_UNKNOWN=1", "DISCOVERY_HOME=/opt/discovery", "DISCOVERY_SERVER=a"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(50))
+			Expect(warnings).Should(HaveLen(3))
+			Expect(warnings[0].Path).Should(Equal("DISCOVERY_SERVER_UNKNOWN"))
+		})
+
+		It("should not panic on unknown keys when loading the defaults", func() {
+			os.Setenv("DISCOVERY_UNRELATED_SETTING", "1")
+			defer os.Unsetenv("DISCOVERY_UNRELATED_SETTING")
+			Expect(func() { NewYamlConfigOrDie(defaults) }).ShouldNot(Panic())
+		})
+
+		It("should set the keys of the schema absent from the config files", func() {
+			cfg, warnings, err := loadConfig("pattern:\n  cert: [\".pem\"]\n", nil, []string{
+				"DISCOVERY_SERVER_CONNECT_PARALLELISM=3",
+				"DISCOVERY_ENV_DENYLIST=PATH,HOME",
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(warnings).Should(BeEmpty())
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(3))
+			Expect(cfg.Env.Denylist).Should(Equal([]string{"PATH", "HOME"}))
+		})
+
+		It("should skip invalid values with a warning and keep the value of the files", func() {
+			cfg, warnings, err := loadConfig(defaults, nil, []string{
+				"DISCOVERY_PATTERN_LOGGING_FILE_PATTERNS=log4j2(\\.xml",
+				"DISCOVERY_SERVER_CONNECT_PARALLELISM=ten",
+				"DISCOVERY_SERVER_CONNECT_PARALLEL=false",
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(warnings).Should(HaveLen(2))
+			Expect(warnings[0].Path).Should(Equal("DISCOVERY_PATTERN_LOGGING_FILE_PATTERNS"))
+			Expect(warnings[1].Path).Should(Equal("DISCOVERY_SERVER_CONNECT_PARALLELISM"))
+			Expect(cfg.Pattern.Logging.FilePatterns).ShouldNot(ContainElement("log4j2(\\.xml"))
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(50))
+			Expect(cfg.Server.Connect.Parallel).Should(BeFalse())
+		})
+
+		It("should not panic on invalid values when loading the defaults", func() {
+			GinkgoT().Setenv("DISCOVERY_SERVER_CONNECT_PARALLELISM", "ten")
+			warnings := YamlCfgWarnings
+			defer func() { YamlCfgWarnings = warnings }()
+			var cfg YamlConfig
+			Expect(func() { cfg = NewYamlConfigOrDie(defaults) }).ShouldNot(Panic())
+			Expect(cfg.Server.Connect.Parallelism).Should(Equal(50))
+			Expect(YamlCfgWarnings).Should(HaveLen(1))
+		})
+	})
+
+	When("user file is invalid", func() {
+		It("should report the file", func() {
+			f := writeFile("user.yml", "pattern:\n  app: !append\n    - \"a(b\"\n")
+			_, _, err := loadConfig(defaults, []string{f}, nil)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring(f))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package springboot
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strings"
+)
+
+type configRule struct {
+	path     []string
+	validate func(value string) error
+}
+
+var configRules = []configRule{
+	{path: []string{"pattern", "app"}, validate: validateRegex},
+	{path: []string{"pattern", "logging", "file_patterns"}, validate: validateRegex},
+	{path: []string{"pattern", "logging", "console_output", "patterns"}, validate: validateRegex},
+	{path: []string{"pattern", "logging", "console_output", "yamlpath"}, validate: validateYamlPath},
+}
+
+// ValidateConfig checks the config content against YamlConfig, and reports every invalid regex and yamlpath with its location
+func ValidateConfig(content []byte) []ConfigError {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return []ConfigError{{error: errors.Wrap(err, "malformed yaml")}}
+	}
+	return validateConfigNode(&root)
+}
+
+func validateConfigNode(root *yaml.Node) []ConfigError {
+	var errs []ConfigError
+	var yg YamlConfig
+	if err := root.Decode(&yg); err != nil {
+		var typeError *yaml.TypeError
+		if errors.As(err, &typeError) {
+			for _, e := range typeError.Errors {
+				errs = append(errs, ConfigError{error: errors.New(e)})
+			}
+		} else {
+			errs = append(errs, ConfigError{error: err})
+		}
+	}
+
+	for _, rule := range configRules {
+		node := lookupNode(root, rule.path...)
+		if node == nil {
+			continue
+		}
+		path := strings.Join(rule.path, ".")
+		if node.Kind != yaml.SequenceNode {
+			errs = append(errs, ConfigError{error: errors.New("expect a list"), Path: path, Line: node.Line, Column: node.Column, Value: node.Value})
+			continue
+		}
+		for i, item := range node.Content {
+			if err := rule.validate(item.Value); err != nil {
+				errs = append(errs, ConfigError{error: err, Path: fmt.Sprintf("%s[%d]", path, i), Line: item.Line, Column: item.Column, Value: item.Value})
+			}
+		}
+	}
+
+	if node := lookupNode(root, "server", "connect", "parallelism"); node != nil && yg.Server.Connect.Parallel && yg.Server.Connect.Parallelism <= 0 {
+		errs = append(errs, ConfigError{error: errors.New("parallelism must be greater than 0 when parallel is enabled"), Path: "server.connect.parallelism", Line: node.Line, Column: node.Column, Value: node.Value})
+	}
+
+	return errs
+}
+
+func validateRegex(value string) error {
+	_, err := regexp.Compile(value)
+	return err
+}
+
+func validateYamlPath(value string) error {
+	_, err := yamlpath.NewPath(value)
+	return err
+}
+
+// lookupNode walks down the mapping nodes by keys, returns nil if any key is absent
+func lookupNode(node *yaml.Node, keys ...string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	for _, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var found *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				found = node.Content[i+1]
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		node = found
+	}
+	return node
+}
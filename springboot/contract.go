@@ -53,10 +53,14 @@ type Runtime struct {
 	JavaCmd           string   `json:"javaCmd"`
 	Environments      []string `json:"environments"`
 	JvmOptions        []string `json:"jvmOptions"`
-	JvmMemory         int64    `json:"jvmMemory"`
-	OsName            string   `json:"osName"`
-	OsVersion         string   `json:"osVersion"`
-	BindingPorts      []int    `json:"bindingPorts"`
+	// JvmOptionSources are JvmOptions with the source of each, the env variables, the command line or an argfile
+	JvmOptionSources []JvmOption `json:"jvmOptionSources,omitempty"`
+	// JdkRelease is the JDK resolved by the executable of the process
+	JdkRelease   *JdkRelease `json:"jdkRelease,omitempty"`
+	JvmMemory    int64       `json:"jvmMemory"`
+	OsName       string      `json:"osName"`
+	OsVersion    string      `json:"osVersion"`
+	BindingPorts []int       `json:"bindingPorts"`
 }
 
 type SpringBootApp struct {
@@ -84,10 +88,23 @@ type Credential struct {
 	Username       string `json:"UserName,omitempty"`
 	Password       string `json:"Password,omitempty"`
 	CredentialType string `json:"CredentialType,omitempty"`
+	// Escalation runs the commands denied to the user with the privileges, nil is passwordless sudo
+	Escalation *Escalation `json:"Escalation,omitempty"`
 }
 
+// DiscoveryCallback receives either an app as soon as it is discovered, or the error of a process failed to be discovered
+type DiscoveryCallback func(app *SpringBootApp, err error)
+
 type DiscoveryExecutor interface {
 	Discover(ctx context.Context, server ServerConnectionInfo, alternativeConnectionInfos ...ServerConnectionInfo) ([]*SpringBootApp, error)
+	// DiscoverStream is the streaming variant of Discover, the returned error is the one failing the whole server, e.g. the connection error
+	DiscoverStream(ctx context.Context, callback DiscoveryCallback, server ServerConnectionInfo, alternativeConnectionInfos ...ServerConnectionInfo) error
+}
+
+// JarCache keeps the jar facts by the sha256 checksum, so the same jar is not read again on other servers or runs
+type JarCache interface {
+	Get(checksum string) (*JarFacts, bool)
+	Put(checksum string, facts *JarFacts) error
 }
 
 type CredentialProvider interface {
@@ -131,9 +148,11 @@ type JavaProcess interface {
 	GetProcessId() int
 	GetUid() int
 	GetRuntimeJdkVersion() (string, error)
+	GetJdkRelease() (*JdkRelease, error)
 	LocateJarFile() (string, error)
 	GetJavaCmd() (string, error)
 	GetJvmOptions() ([]string, error)
+	GetJvmOptionSources() ([]JvmOption, error)
 	GetEnvironments() ([]string, error)
 	GetJvmMemory() (int64, error)
 	GetPorts() ([]int, error)
@@ -146,6 +165,9 @@ type ServerConnector interface {
 	Close() error
 	Read(remoteLocation string) (io.ReaderAt, os.FileInfo, error)
 	RunCmd(cmd string) (string, error)
+	// Escalate sets the escalation of the connected credential, RunPrivilegedCmd runs the command by it
+	Escalate(escalation *Escalation)
+	RunPrivilegedCmd(cmd string) (string, error)
 	Username() string
 }
 
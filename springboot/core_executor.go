@@ -10,26 +10,45 @@ type springBootDiscoveryExecutor struct {
 	credentialProvider     CredentialProvider
 	serverConnectorFactory ServerConnectorFactory
 	cfg                    YamlConfig
+	opts                   []DiscoveryOption
 }
 
 func NewSpringBootDiscoveryExecutor(
 	credentialProvider CredentialProvider,
 	serverConnectorFactory ServerConnectorFactory,
 	cfg YamlConfig,
+	opts ...DiscoveryOption,
 ) DiscoveryExecutor {
 	return &springBootDiscoveryExecutor{
 		credentialProvider:     credentialProvider,
 		serverConnectorFactory: serverConnectorFactory,
 		cfg:                    cfg,
+		opts:                   opts,
 	}
 }
 
 func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnectionInfo ServerConnectionInfo, alternativeConnectionInfos ...ServerConnectionInfo) ([]*SpringBootApp, error) {
+	var apps []*SpringBootApp
+	var errs []error
+	err := s.DiscoverStream(ctx, func(app *SpringBootApp, err error) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			apps = append(apps, app)
+		}
+	}, serverConnectionInfo, alternativeConnectionInfos...)
+	if err != nil {
+		return nil, err
+	}
+	return apps, Join(errs...)
+}
+
+func (s *springBootDiscoveryExecutor) DiscoverStream(ctx context.Context, callback DiscoveryCallback, serverConnectionInfo ServerConnectionInfo, alternativeConnectionInfos ...ServerConnectionInfo) error {
 	azureLogger := GetAzureLogger(ctx)
 	var err error
 	serverDiscovery, cred, err := s.tryConnect(ctx, append([]ServerConnectionInfo{serverConnectionInfo}, alternativeConnectionInfos...))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	azureLogger.Info("connect to serverConnectionInfo successfully", "credential", cred.FriendlyName, "runAsAccountId", cred.Id, "host", serverDiscovery.Server().FQDN())
 	defer serverDiscovery.Finish()
@@ -37,13 +56,11 @@ func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnec
 	var processes []JavaProcess
 	processes, err = serverDiscovery.ProcessScan()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	azureLogger.Info("process scanned", "length", len(processes), "host", serverDiscovery.Server().FQDN())
 
 	var jarCache = make(map[string]JarFile)
-	var apps []*SpringBootApp
-	var errs []error
 	for _, process := range processes {
 		azureLogger.Info("begin to discover process", "processId", process.GetProcessId(), "host", serverDiscovery.Server().FQDN())
 		var app *SpringBootApp
@@ -53,7 +70,7 @@ func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnec
 		jarLocation, errInLoop = process.LocateJarFile()
 		if errInLoop != nil {
 			azureLogger.Warning(errInLoop, "locate jar file failed", "host", serverDiscovery.Server().FQDN())
-			errs = append(errs, errInLoop)
+			callback(nil, errInLoop)
 			continue
 		}
 
@@ -65,7 +82,7 @@ func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnec
 			jar, errInLoop = process.Executor().ReadJarFile(jarLocation, DefaultJarFileWalkers...)
 			if errInLoop != nil {
 				azureLogger.Error(errInLoop, "read jar file failed", "location", jarLocation, "error", errInLoop.Error(), "host", serverDiscovery.Server().FQDN())
-				errs = append(errs, errInLoop)
+				callback(nil, errInLoop)
 				continue
 			}
 		}
@@ -73,7 +90,7 @@ func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnec
 		app, errInLoop = s.discoverApp(process, jar)
 		if errInLoop != nil {
 			azureLogger.Warning(errInLoop, "discover app failed", "location", jarLocation, "process", process.GetProcessId(), "error", errInLoop.Error(), "host", serverDiscovery.Server().FQDN())
-			errs = append(errs, errInLoop)
+			callback(nil, errInLoop)
 			continue
 		}
 
@@ -89,10 +106,10 @@ func (s *springBootDiscoveryExecutor) Discover(ctx context.Context, serverConnec
 
 		azureLogger.Info("finished to discover process, found app", "processId", process.GetProcessId(), "app", app.AppName, "host", serverDiscovery.Server().FQDN())
 
-		apps = append(apps, app)
+		callback(app, nil)
 	}
 
-	return apps, Join(errs...)
+	return nil
 }
 
 var getAppName StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
@@ -167,6 +184,10 @@ var getRuntimeJdkVersion StepFunc = func(process JavaProcess, jarFile JarFile) *
 	return Of(process.GetRuntimeJdkVersion()).Field("RuntimeJdkVersion")
 }
 
+var getJdkRelease StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
+	return Of(process.GetJdkRelease()).Field("JdkRelease")
+}
+
 var getJvmMemory StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
 	return Of(process.GetJvmMemory()).Field("JvmMemory")
 }
@@ -179,6 +200,10 @@ var getJvmOptions StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
 	return Of(process.GetJvmOptions()).Field("JvmOptions")
 }
 
+var getJvmOptionSources StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
+	return Of(process.GetJvmOptionSources()).Field("JvmOptionSources")
+}
+
 var getBindingPorts StepFunc = func(process JavaProcess, jarFile JarFile) *Monad {
 	return Of(process.GetPorts()).Field("BindingPorts")
 }
@@ -242,9 +267,11 @@ func (s *springBootDiscoveryExecutor) discoverApp(process JavaProcess, jar JarFi
 		Apply(getJavaCmd).
 		Apply(getServer).
 		Apply(getRuntimeJdkVersion).
+		Apply(getJdkRelease).
 		Apply(getJvmMemory).
 		Apply(getEnvironments).
 		Apply(getJvmOptions).
+		Apply(getJvmOptionSources).
 		Apply(getBindingPorts).
 		Apply(getOsName).
 		Apply(getOsVersion).
@@ -284,6 +311,11 @@ func sanitizeArtifactName(artifactName string) string {
 	return Patterns.MavenPomVersionPattern.ReplaceAllString(artifactName, "")
 }
 
+// DependencyName returns the name of the dependency jar without the version, e.g. spring-core for spring-core-5.3.1.jar
+func DependencyName(jar string) string {
+	return sanitizeArtifactName(jar)
+}
+
 func (s *springBootDiscoveryExecutor) tryConnect(ctx context.Context, serverConnectionInfos []ServerConnectionInfo) (ServerDiscovery, *Credential, error) {
 	azureLogger := GetAzureLogger(ctx)
 	var serverDiscovery ServerDiscovery
@@ -299,6 +331,7 @@ func (s *springBootDiscoveryExecutor) tryConnect(ctx context.Context, serverConn
 			s.serverConnectorFactory.Create(ctx, info.Server, info.Port),
 			s.credentialProvider,
 			s.cfg,
+			s.opts...,
 		)
 
 		if cred, err = serverDiscovery.Prepare(); err != nil {
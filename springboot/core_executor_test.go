@@ -1,6 +1,7 @@
 package springboot
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"fmt"
@@ -13,10 +14,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 var osName = "ubuntu"
-var osVersion = "1804"
+var osVersion = "18.04"
 var fqdn = "test-fqdn"
 var testUser = "test-user"
 
@@ -81,6 +83,32 @@ var _ = Describe("Test springboot discovery executor", func() {
 		})
 	})
 
+	When("apps are streamed", func() {
+		It("should call back once per app in the order of the processes before returning", func() {
+			credentialProvider.EXPECT().GetCredentials().Return(credentials, nil).AnyTimes()
+			serverConnector.EXPECT().FQDN().Return(fqdn).AnyTimes()
+			serverConnector.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			serverConnectorFactory.EXPECT().Create(gomock.Any(), fqdn, gomock.Any()).Return(serverConnector).AnyTimes()
+			setupServerCommandMock(serverConnector, strings.Join([]string{SpringBoot2xProcess, SpringBoot1xProcess}, "\n"))
+			for location, version := range map[string]string{SpringBoot2xJarFileLocation: SpringBoot2xVersion, SpringBoot1xJarFileLocation: SpringBoot1xVersion} {
+				jar := newFatJar(version)
+				serverConnector.EXPECT().Read(location).Return(bytes.NewReader(jar), &mockFileInfo{size: int64(len(jar))}, nil).Times(1)
+			}
+
+			var locations []string
+			var returned bool
+			err := executor.DiscoverStream(context.Background(), func(app *SpringBootApp, err error) {
+				Expect(returned).Should(BeFalse())
+				Expect(err).ShouldNot(HaveOccurred())
+				locations = append(locations, app.JarFileLocation)
+			}, ServerConnectionInfo{Server: fqdn, Port: 1022})
+			returned = true
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(locations).Should(Equal([]string{SpringBoot2xJarFileLocation, SpringBoot1xJarFileLocation}))
+		})
+	})
+
 	When("server is not accessible", func() {
 		It("discovery should be failed", func() {
 			serverConnectorFactory.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(serverConnector).AnyTimes()
@@ -98,6 +126,22 @@ var _ = Describe("Test springboot discovery executor", func() {
 			Expect(apps).Should(BeNil())
 			Expect(err).Should(Not(BeNil()))
 		})
+
+		It("streaming discovery should be failed without callback", func() {
+			serverConnectorFactory.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(serverConnector).AnyTimes()
+			credentialProvider.EXPECT().GetCredentials().Return(credentials, nil).AnyTimes()
+			serverConnector.EXPECT().FQDN().Return(fqdn).AnyTimes()
+			serverConnector.EXPECT().Connect(gomock.Any(), gomock.Any()).Return(fmt.Errorf("connection error")).AnyTimes()
+			serverConnector.EXPECT().Close().MinTimes(1)
+
+			var called bool
+			err := executor.DiscoverStream(context.Background(), func(app *SpringBootApp, err error) {
+				called = true
+			}, ServerConnectionInfo{Server: fqdn, Port: 1022})
+
+			Expect(called).Should(BeFalse())
+			Expect(err).Should(Not(BeNil()))
+		})
 	})
 
 	When("primary fqdn is not accessible but alternative IP address is accessible", func() {
@@ -209,6 +253,10 @@ func MatchFatJar(app string, jdkVersion string, springBootVersion string, jarLoc
 			"AppPort":           Equal(8080),
 			"JavaCmd":           Not(BeEmpty()),
 			"RuntimeJdkVersion": MatchVersion("11"),
+			"JdkRelease": PointTo(MatchFields(IgnoreExtras, Fields{
+				"JavaHome": Equal("/usr/lib/jvm/java-11-openjdk-amd64"),
+				"Vendor":   Equal(JdkVendorTemurin),
+			})),
 			"Environments": And(
 				ContainElement(Equal("test_option=test")),
 				ContainElement(Equal("DB_PASSWORD=testpassword1234")),
@@ -284,28 +332,7 @@ func MatchExecutableJar(app string, jarLocation string) types.GomegaMatcher {
 }
 
 func setupServerConnectorMock(s *MockServerConnector, processes string) {
-	s.EXPECT().Close().AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetLocateJarCmd(SpringBoot2xProcessId, SpringBoot2xJarFile))).Return(SpringBoot2xJarFileLocation, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetEnvCmd(SpringBoot2xProcessId))).Return(TestEnv, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetPortsCmd(SpringBoot2xProcessId))).Return(Ports, nil).AnyTimes()
-
-	s.EXPECT().RunCmd(gomock.Eq(GetLocateJarCmd(SpringBoot1xProcessId, SpringBoot1xJarFile))).Return(SpringBoot1xJarFileLocation, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetEnvCmd(SpringBoot1xProcessId))).Return(TestEnv, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetPortsCmd(SpringBoot1xProcessId))).Return(Ports, nil).AnyTimes()
-
-	s.EXPECT().RunCmd(gomock.Eq(GetLocateJarCmd(ExecutableProcessId, ExecutableJarFile))).Return(ExecutableJarFileLocation, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetEnvCmd(ExecutableProcessId))).Return(TestEnv, nil).AnyTimes()
-	s.EXPECT().RunCmd(gomock.Eq(GetPortsCmd(ExecutableProcessId))).Return(Ports, nil).AnyTimes()
-
-	s.EXPECT().RunCmd(gomock.Eq(GetProcessScanCmd())).Return(processes, nil).AnyTimes()
-
-	s.EXPECT().RunCmd(CmdMatcher(LinuxGetTotalMemoryCmd)).Return(TotalMemory, nil).AnyTimes()
-	s.EXPECT().RunCmd(CmdMatcher(LinuxGetJdkVersionCmd)).Return(RuntimeJdkVersion, nil).AnyTimes()
-	s.EXPECT().RunCmd(CmdMatcher(LinuxGetDefaultMaxHeapCmd)).Return(DefaultMaxHeapSize, nil).AnyTimes()
-	s.EXPECT().RunCmd(CmdMatcher(LinuxSha256Cmd)).Return("", nil).AnyTimes()
-	s.EXPECT().RunCmd(CmdMatcher(GetOsName())).Return(osName, nil).AnyTimes()
-	s.EXPECT().RunCmd(CmdMatcher(GetOsVersion())).Return(osVersion, nil).AnyTimes()
-	//s.EXPECT().FQDN().Return(Host).AnyTimes()
+	setupServerCommandMock(s, processes)
 
 	b, err := os.ReadFile(filepath.Join("..", "mock", SpringBoot2xJarFile))
 	if err != nil {
@@ -328,3 +355,64 @@ func setupServerConnectorMock(s *MockServerConnector, processes string) {
 	info, _ = os.Stat(filepath.Join("..", "mock", ExecutableJarFile))
 	s.EXPECT().Read(gomock.Eq(ExecutableJarFileLocation)).Return(bytes.NewReader(b), info, nil).AnyTimes()
 }
+
+// setupServerCommandMock mocks the commands run on the server, the jar files are mocked by the caller
+func setupServerCommandMock(s *MockServerConnector, processes string) {
+	s.EXPECT().Close().AnyTimes()
+	s.EXPECT().Escalate(gomock.Any()).AnyTimes()
+	for _, pid := range []int{SpringBoot2xProcessId, SpringBoot1xProcessId, ExecutableProcessId} {
+		s.EXPECT().RunCmd(gomock.Eq(GetProcStatusCmd(pid))).Return(ProcStatus, nil).AnyTimes()
+		s.EXPECT().RunCmd(gomock.Eq(GetFdLinksCmd(pid))).Return(FdLinks, nil).AnyTimes()
+		s.EXPECT().RunCmd(gomock.Eq(GetEnvCmd(pid))).Return(TestEnv, nil).AnyTimes()
+		s.EXPECT().RunCmd(gomock.Eq(GetNetTcpCmd(pid))).Return(NetTcp, nil).AnyTimes()
+		s.EXPECT().RunCmd(gomock.Eq(GetNetTcp6Cmd(pid))).Return(NetTcp6, nil).AnyTimes()
+		s.EXPECT().RunCmd(gomock.Eq(GetExeCmd(pid))).Return(fmt.Sprintf("lrwxrwxrwx 1 app app 0 Feb  5 10:04 /proc/%d/exe -> %s\n", pid, JavaExe), nil).AnyTimes()
+	}
+
+	s.EXPECT().RunCmd(gomock.Eq(GetProcessScanCmd())).Return(processes, nil).AnyTimes()
+
+	s.EXPECT().RunCmd(CmdMatcher(LinuxGetTotalMemoryCmd)).Return(TotalMemory, nil).AnyTimes()
+	s.EXPECT().RunCmd(CmdMatcher(LinuxGetJdkReleaseCmd)).Return(JdkReleaseFile, nil).AnyTimes()
+	s.EXPECT().RunCmd(CmdMatcher(LinuxGetJdkVersionCmd)).Return(RuntimeJdkVersion, nil).AnyTimes()
+	s.EXPECT().RunCmd(CmdMatcher(LinuxGetDefaultMaxHeapCmd)).Return(DefaultMaxHeapSize, nil).AnyTimes()
+	s.EXPECT().RunCmd(CmdMatcher(LinuxSha256Cmd)).Return("", nil).AnyTimes()
+	s.EXPECT().RunCmd(CmdMatcher(GetOsReleaseCmd())).Return(OsRelease, nil).AnyTimes()
+	//s.EXPECT().FQDN().Return(Host).AnyTimes()
+}
+
+// mockFileInfo is the file info of a jar built in memory
+type mockFileInfo struct {
+	size int64
+}
+
+func (m *mockFileInfo) Name() string       { return "app.jar" }
+func (m *mockFileInfo) Size() int64        { return m.size }
+func (m *mockFileInfo) Mode() os.FileMode  { return 0644 }
+func (m *mockFileInfo) ModTime() time.Time { return time.Date(2023, 2, 5, 9, 24, 40, 0, time.UTC) }
+func (m *mockFileInfo) IsDir() bool        { return false }
+func (m *mockFileInfo) Sys() any           { return nil }
+
+// newFatJar builds a minimal spring boot fat jar of the version in memory
+func newFatJar(springBootVersion string) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range map[string]string{
+		"META-INF/MANIFEST.MF": fmt.Sprintf("Manifest-Version: 1.0\r\nMain-Class: %s\r\nStart-Class: com.example.Application\r\n%s: %s\r\n",
+			PropertiesLauncherClassName, SpringBootVersionField, springBootVersion),
+		fmt.Sprintf("BOOT-INF/lib/spring-boot-%s.jar", springBootVersion): "",
+		"META-INF/maven/com.example/hellospring/pom.xml": fmt.Sprintf("<project><parent><groupId>%s</groupId><artifactId>%s</artifactId><version>%s</version></parent>"+
+			"<groupId>com.example</groupId><artifactId>hellospring</artifactId><version>0.0.1</version></project>", SpringBootStarterGroupId, SpringBootStarterArtifactId, springBootVersion),
+	} {
+		f, err := w.Create(name)
+		if err != nil {
+			panic(err)
+		}
+		if _, err = f.Write([]byte(content)); err != nil {
+			panic(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,301 @@
+package springboot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/docker/go-units"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ChangeCategory string
+
+type ChangeKind string
+
+const (
+	VersionChange    ChangeCategory = "Version"
+	ArtifactChange   ChangeCategory = "Artifact"
+	DependencyChange ChangeCategory = "Dependency"
+	ConfigChange     ChangeCategory = "Configuration"
+	RuntimeChange    ChangeCategory = "Runtime"
+
+	Added    ChangeKind = "Added"
+	Removed  ChangeKind = "Removed"
+	Modified ChangeKind = "Modified"
+)
+
+type Change struct {
+	Category ChangeCategory `json:"category"`
+	Kind     ChangeKind     `json:"kind"`
+	Field    string         `json:"field"`
+	Before   string         `json:"before,omitempty"`
+	After    string         `json:"after,omitempty"`
+}
+
+type AppDiff struct {
+	Key     string   `json:"key"`
+	AppName string   `json:"appName"`
+	Changes []Change `json:"changes"`
+}
+
+type ScanDiff struct {
+	Added     []*SpringBootApp `json:"added"`
+	Removed   []*SpringBootApp `json:"removed"`
+	Changed   []AppDiff        `json:"changed"`
+	Unchanged int              `json:"unchanged"`
+}
+
+// AppKey identifies an app across scans by the server and the jar file location
+func AppKey(app *SpringBootApp) string {
+	var server string
+	if app.Runtime != nil {
+		server = app.Runtime.Server
+	}
+	return server + ":" + app.JarFileLocation
+}
+
+// AppId is a short id derived from AppKey, so the same app keeps the same id across scans
+func AppId(app *SpringBootApp) string {
+	sum := sha256.Sum256([]byte(AppKey(app)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// DiffScans compares two scan results, apps are matched by AppKey
+func DiffScans(before, after []*SpringBootApp) *ScanDiff {
+	diff := &ScanDiff{}
+	var beforeByKey = make(map[string]*SpringBootApp)
+	for _, app := range before {
+		beforeByKey[AppKey(app)] = app
+	}
+
+	var matched = make(map[string]bool)
+	for _, app := range after {
+		key := AppKey(app)
+		previous, ok := beforeByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, app)
+			continue
+		}
+		matched[key] = true
+		if changes := DiffApp(previous, app); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, AppDiff{Key: key, AppName: app.AppName, Changes: changes})
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for _, app := range before {
+		if !matched[AppKey(app)] {
+			diff.Removed = append(diff.Removed, app)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return AppKey(diff.Added[i]) < AppKey(diff.Added[j]) })
+	sort.Slice(diff.Removed, func(i, j int) bool { return AppKey(diff.Removed[i]) < AppKey(diff.Removed[j]) })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key < diff.Changed[j].Key })
+	return diff
+}
+
+// DiffApp lists the changes between two scans of the same app
+func DiffApp(before, after *SpringBootApp) []Change {
+	var changes []Change
+	compare := func(category ChangeCategory, field string, b, a string) {
+		if b != a {
+			changes = append(changes, Change{Category: category, Kind: Modified, Field: field, Before: b, After: a})
+		}
+	}
+
+	beforeArtifact, afterArtifact := artifactOf(before), artifactOf(after)
+	beforeRuntime, afterRuntime := runtimeOf(before), runtimeOf(after)
+
+	compare(VersionChange, "springBootVersion", before.SpringBootVersion, after.SpringBootVersion)
+	compare(VersionChange, "buildJdkVersion", before.BuildJdkVersion, after.BuildJdkVersion)
+	compare(VersionChange, "runtimeJdkVersion", beforeRuntime.RuntimeJdkVersion, afterRuntime.RuntimeJdkVersion)
+	compare(VersionChange, "artifact.version", beforeArtifact.Version, afterArtifact.Version)
+
+	compare(ArtifactChange, "appName", before.AppName, after.AppName)
+	compare(ArtifactChange, "appType", string(before.AppType), string(after.AppType))
+	compare(ArtifactChange, "artifact.group", beforeArtifact.Group, afterArtifact.Group)
+	compare(ArtifactChange, "artifact.name", beforeArtifact.Name, afterArtifact.Name)
+	compare(ArtifactChange, "checksum", before.Checksum, after.Checksum)
+	compare(ArtifactChange, "jarSize", units.BytesSize(float64(before.JarSize)), units.BytesSize(float64(after.JarSize)))
+	compare(ArtifactChange, "lastModifiedTime", formatTime(before.LastModifiedTime), formatTime(after.LastModifiedTime))
+
+	changes = append(changes, diffDependencies(before.Dependencies, after.Dependencies)...)
+	changes = append(changes, diffValues(ConfigChange, "applicationConfigurations", before.ApplicationConfigurations, after.ApplicationConfigurations)...)
+	changes = append(changes, diffValues(ConfigChange, "loggingConfigurations", before.LoggingConfigurations, after.LoggingConfigurations)...)
+	changes = append(changes, diffSet(ConfigChange, "certificates", before.Certificates, after.Certificates)...)
+
+	compare(RuntimeChange, "jvmMemory", units.BytesSize(float64(beforeRuntime.JvmMemory)), units.BytesSize(float64(afterRuntime.JvmMemory)))
+	compare(RuntimeChange, "appPort", strconv.Itoa(beforeRuntime.AppPort), strconv.Itoa(afterRuntime.AppPort))
+	compare(RuntimeChange, "bindingPorts", joinPorts(beforeRuntime.BindingPorts), joinPorts(afterRuntime.BindingPorts))
+	compare(RuntimeChange, "javaCmd", beforeRuntime.JavaCmd, afterRuntime.JavaCmd)
+	compare(RuntimeChange, "osName", beforeRuntime.OsName, afterRuntime.OsName)
+	compare(RuntimeChange, "osVersion", beforeRuntime.OsVersion, afterRuntime.OsVersion)
+	changes = append(changes, diffSet(RuntimeChange, "jvmOptions", beforeRuntime.JvmOptions, afterRuntime.JvmOptions)...)
+	changes = append(changes, diffValues(RuntimeChange, "environments", envMap(beforeRuntime.Environments), envMap(afterRuntime.Environments))...)
+
+	return changes
+}
+
+// diffDependencies pairs the removed and added jars by the name without version, so an upgrade is reported as one change
+func diffDependencies(before, after []string) []Change {
+	var changes []Change
+	removed, added := subtract(before, after), subtract(after, before)
+
+	// the jars of the same name are paired in order, e.g. two versions of a jar shaded into different paths
+	var addedByName = make(map[string][]string)
+	for _, dep := range added {
+		name := sanitizeArtifactName(dep)
+		addedByName[name] = append(addedByName[name], dep)
+	}
+	var paired = make(map[string]bool)
+	for _, dep := range removed {
+		name := sanitizeArtifactName(dep)
+		if upgraded := addedByName[name]; len(upgraded) > 0 {
+			changes = append(changes, Change{Category: DependencyChange, Kind: Modified, Field: name, Before: dep, After: upgraded[0]})
+			paired[upgraded[0]] = true
+			addedByName[name] = upgraded[1:]
+		} else {
+			changes = append(changes, Change{Category: DependencyChange, Kind: Removed, Field: name, Before: dep})
+		}
+	}
+	for _, dep := range added {
+		if !paired[dep] {
+			changes = append(changes, Change{Category: DependencyChange, Kind: Added, Field: sanitizeArtifactName(dep), After: dep})
+		}
+	}
+	return changes
+}
+
+// diffValues reports the added, removed and modified keys, the values are not included as they may carry secrets
+func diffValues(category ChangeCategory, field string, before, after map[string]string) []Change {
+	var changes []Change
+	for _, name := range sortedKeys(before) {
+		if content, ok := after[name]; !ok {
+			changes = append(changes, Change{Category: category, Kind: Removed, Field: field + "[" + name + "]"})
+		} else if content != before[name] {
+			changes = append(changes, Change{Category: category, Kind: Modified, Field: field + "[" + name + "]"})
+		}
+	}
+	for _, name := range sortedKeys(after) {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, Change{Category: category, Kind: Added, Field: field + "[" + name + "]"})
+		}
+	}
+	return changes
+}
+
+func diffSet(category ChangeCategory, field string, before, after []string) []Change {
+	var changes []Change
+	for _, value := range subtract(before, after) {
+		changes = append(changes, Change{Category: category, Kind: Removed, Field: field, Before: value})
+	}
+	for _, value := range subtract(after, before) {
+		changes = append(changes, Change{Category: category, Kind: Added, Field: field, After: value})
+	}
+	return changes
+}
+
+// Summary renders the diff in a human-readable way
+func (d *ScanDiff) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d added, %d removed, %d changed, %d unchanged\n", len(d.Added), len(d.Removed), len(d.Changed), d.Unchanged)
+	for _, app := range d.Added {
+		fmt.Fprintf(&sb, "+ %s (%s)\n", AppKey(app), app.AppName)
+	}
+	for _, app := range d.Removed {
+		fmt.Fprintf(&sb, "- %s (%s)\n", AppKey(app), app.AppName)
+	}
+	for _, changed := range d.Changed {
+		fmt.Fprintf(&sb, "~ %s (%s)\n", changed.Key, changed.AppName)
+		for _, c := range changed.Changes {
+			fmt.Fprintf(&sb, "    %s\n", c)
+		}
+	}
+	return sb.String()
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return strings.TrimSpace(fmt.Sprintf("[%s] + %s %s", c.Category, c.Field, c.After))
+	case Removed:
+		return strings.TrimSpace(fmt.Sprintf("[%s] - %s %s", c.Category, c.Field, c.Before))
+	default:
+		if len(c.Before) == 0 && len(c.After) == 0 {
+			return fmt.Sprintf("[%s] ~ %s", c.Category, c.Field)
+		}
+		return fmt.Sprintf("[%s] ~ %s: %s -> %s", c.Category, c.Field, c.Before, c.After)
+	}
+}
+
+func artifactOf(app *SpringBootApp) *Artifact {
+	if app.Artifact == nil {
+		return &Artifact{}
+	}
+	return app.Artifact
+}
+
+func runtimeOf(app *SpringBootApp) *Runtime {
+	if app.Runtime == nil {
+		return &Runtime{}
+	}
+	return app.Runtime
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func joinPorts(ports []int) string {
+	var sorted = append([]int(nil), ports...)
+	sort.Ints(sorted)
+	var s []string
+	for _, port := range sorted {
+		s = append(s, strconv.Itoa(port))
+	}
+	return strings.Join(s, ",")
+}
+
+func envMap(envs []string) map[string]string {
+	var m = make(map[string]string)
+	for _, env := range envs {
+		if idx := strings.Index(env, "="); idx > 0 {
+			m[env[:idx]] = env[idx+1:]
+		} else {
+			m[env] = ""
+		}
+	}
+	return m
+}
+
+// subtract returns the values in a but not in b, in the order of a
+func subtract(a, b []string) []string {
+	var set = make(map[string]bool)
+	for _, v := range b {
+		set[v] = true
+	}
+	var result []string
+	for _, v := range a {
+		if !set[v] {
+			result = append(result, v)
+			set[v] = true
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package springboot
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scan diff", func() {
+	var (
+		before []*SpringBootApp
+		after  []*SpringBootApp
+	)
+
+	newApp := func(server, location string) *SpringBootApp {
+		return &SpringBootApp{
+			AppName:                   "hellospring",
+			AppType:                   SpringBootFatJar,
+			JarFileLocation:           location,
+			SpringBootVersion:         SpringBoot2xVersion,
+			BuildJdkVersion:           "11",
+			Checksum:                  "abc",
+			Dependencies:              []string{"spring-boot-2.4.13.jar", "spring-core-5.3.1.jar", "guava-30.0.jar"},
+			ApplicationConfigurations: map[string]string{"application.yml": "server.port: 8080"},
+			LastModifiedTime:          time.Date(2023, 2, 5, 9, 24, 40, 0, time.UTC),
+			Artifact:                  &Artifact{Group: "com.example", Name: "hellospring", Version: "0.0.1"},
+			Runtime: &Runtime{
+				Server:            server,
+				RuntimeJdkVersion: "11.0.16",
+				AppPort:           8080,
+				JvmMemory:         128 * MiB,
+				BindingPorts:      []int{8080, 22},
+				Environments:      []string{"DB_PASSWORD=secret"},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		before = []*SpringBootApp{newApp("host1", "/app/a.jar"), newApp("host1", "/app/b.jar"), newApp("host2", "/app/a.jar")}
+		after = []*SpringBootApp{newApp("host1", "/app/a.jar"), newApp("host2", "/app/a.jar"), newApp("host2", "/app/c.jar")}
+	})
+
+	When("apps are added and removed", func() {
+		It("should match apps by server and jar location", func() {
+			diff := DiffScans(before, after)
+			Expect(diff.Added).Should(HaveLen(1))
+			Expect(AppKey(diff.Added[0])).Should(Equal("host2:/app/c.jar"))
+			Expect(diff.Removed).Should(HaveLen(1))
+			Expect(AppKey(diff.Removed[0])).Should(Equal("host1:/app/b.jar"))
+			Expect(diff.Changed).Should(BeEmpty())
+			Expect(diff.Unchanged).Should(Equal(2))
+		})
+	})
+
+	When("the jars of the same name change", func() {
+		It("should report every one of them", func() {
+			changes := diffDependencies(
+				[]string{"guava-30.0.jar", "guava-31.0.jar", "netty-4.1.0.jar"},
+				[]string{"guava-32.0.jar", "guava-33.0.jar", "guava-31.0.jar", "netty-4.1.0.jar"},
+			)
+			Expect(changes).Should(Equal([]Change{
+				{Category: DependencyChange, Kind: Modified, Field: "guava", Before: "guava-30.0.jar", After: "guava-32.0.jar"},
+				{Category: DependencyChange, Kind: Added, Field: "guava", After: "guava-33.0.jar"},
+			}))
+
+			changes = diffDependencies([]string{"guava-30.0.jar", "guava-31.0.jar"}, []string{"guava-32.0.jar"})
+			Expect(changes).Should(Equal([]Change{
+				{Category: DependencyChange, Kind: Modified, Field: "guava", Before: "guava-30.0.jar", After: "guava-32.0.jar"},
+				{Category: DependencyChange, Kind: Removed, Field: "guava", Before: "guava-31.0.jar"},
+			}))
+		})
+	})
+
+	When("app id is generated", func() {
+		It("should be stable across scans", func() {
+			Expect(AppId(before[0])).Should(HaveLen(12))
+			Expect(AppId(before[0])).Should(Equal(AppId(after[0])))
+			Expect(AppId(before[0])).ShouldNot(Equal(AppId(before[1])))
+		})
+	})
+
+	When("an app is changed", func() {
+		BeforeEach(func() {
+			app := after[0]
+			app.SpringBootVersion = "2.7.0"
+			app.Runtime.RuntimeJdkVersion = "17.0.2"
+			app.Artifact.Version = "0.0.2"
+			app.Checksum = "def"
+			app.Dependencies = []string{"spring-boot-2.7.0.jar", "spring-core-5.3.1.jar", "jackson-core-2.13.0.jar"}
+			app.ApplicationConfigurations = map[string]string{"application.yml": "server.port: 8081", "application-prod.yml": ""}
+			app.Runtime.JvmMemory = 256 * MiB
+			app.Runtime.BindingPorts = []int{22, 8081}
+			app.Runtime.Environments = []string{"DB_PASSWORD=changed"}
+		})
+
+		It("should report every kind of change", func() {
+			diff := DiffScans(before, after)
+			Expect(diff.Changed).Should(HaveLen(1))
+			changes := diff.Changed[0].Changes
+			Expect(changes).Should(ContainElements(
+				Change{Category: VersionChange, Kind: Modified, Field: "springBootVersion", Before: SpringBoot2xVersion, After: "2.7.0"},
+				Change{Category: VersionChange, Kind: Modified, Field: "runtimeJdkVersion", Before: "11.0.16", After: "17.0.2"},
+				Change{Category: VersionChange, Kind: Modified, Field: "artifact.version", Before: "0.0.1", After: "0.0.2"},
+				Change{Category: ArtifactChange, Kind: Modified, Field: "checksum", Before: "abc", After: "def"},
+				Change{Category: DependencyChange, Kind: Modified, Field: "spring-boot", Before: "spring-boot-2.4.13.jar", After: "spring-boot-2.7.0.jar"},
+				Change{Category: DependencyChange, Kind: Removed, Field: "guava", Before: "guava-30.0.jar"},
+				Change{Category: DependencyChange, Kind: Added, Field: "jackson-core", After: "jackson-core-2.13.0.jar"},
+				Change{Category: ConfigChange, Kind: Modified, Field: "applicationConfigurations[application.yml]"},
+				Change{Category: ConfigChange, Kind: Added, Field: "applicationConfigurations[application-prod.yml]"},
+				Change{Category: RuntimeChange, Kind: Modified, Field: "jvmMemory", Before: "128MiB", After: "256MiB"},
+				Change{Category: RuntimeChange, Kind: Modified, Field: "bindingPorts", Before: "22,8080", After: "22,8081"},
+				Change{Category: RuntimeChange, Kind: Modified, Field: "environments[DB_PASSWORD]"},
+			))
+			Expect(diff.Summary()).Should(And(
+				ContainSubstring("1 added, 1 removed, 1 changed, 1 unchanged"),
+				ContainSubstring("[Version] ~ springBootVersion: 2.4.13 -> 2.7.0"),
+				ContainSubstring("[Dependency] + jackson-core jackson-core-2.13.0.jar"),
+				Not(ContainSubstring("secret")),
+			))
+		})
+	})
+
+	When("artifact and runtime are missing", func() {
+		It("should not panic", func() {
+			before[0].Runtime = nil
+			before[0].Artifact = nil
+			Expect(DiffApp(before[0], after[0])).ShouldNot(BeEmpty())
+		})
+	})
+})
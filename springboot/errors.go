@@ -31,6 +31,34 @@ func (pe PermissionDenied) Unwrap() error {
 	return pe.error
 }
 
+// EscalationError is the privileges not granted by the escalation, e.g. a wrong password or not in the sudoers
+type EscalationError struct {
+	error
+	message string
+}
+
+func (ee EscalationError) Error() string {
+	return fmt.Sprintf("privilege escalation failed, cause: %s, message: %s", ee.error, ee.message)
+}
+
+func (ee EscalationError) Unwrap() error {
+	return ee.error
+}
+
+// CommandRejected is the command not on the allowlist, it is never sent to the server
+type CommandRejected struct {
+	error
+	message string
+}
+
+func (ce CommandRejected) Error() string {
+	return fmt.Sprintf("command rejected, cause: %s, command: %s", ce.error, ce.message)
+}
+
+func (ce CommandRejected) Unwrap() error {
+	return ce.error
+}
+
 type ConnectionError struct {
 	error
 	message string
@@ -65,6 +93,25 @@ func (ce CredentialError) Unwrap() error {
 	return ce.error
 }
 
+type ConfigError struct {
+	error
+	Path   string
+	Line   int
+	Column int
+	Value  string
+}
+
+func (ce ConfigError) Error() string {
+	if len(ce.Path) == 0 {
+		return fmt.Sprintf("invalid config, cause: %s", ce.error)
+	}
+	return fmt.Sprintf("invalid config at %s (line %d, column %d), value: %q, cause: %s", ce.Path, ce.Line, ce.Column, ce.Value, ce.error)
+}
+
+func (ce ConfigError) Unwrap() error {
+	return ce.error
+}
+
 func Join(errs ...error) error {
 	if len(errs) == 0 {
 		return nil
@@ -105,10 +152,22 @@ func IsPermissionDenied(err error) bool {
 	return is(err, &PermissionDenied{})
 }
 
+func IsEscalationError(err error) bool {
+	return is(err, &EscalationError{})
+}
+
+func IsCommandRejected(err error) bool {
+	return is(err, &CommandRejected{})
+}
+
 func IsJoinErrors(err error) bool {
 	return is(err, &JoinErrors{})
 }
 
+func IsConfigError(err error) bool {
+	return is(err, &ConfigError{})
+}
+
 func is(from, to error) bool {
 	return errors.As(from, to)
 }
@@ -0,0 +1,171 @@
+package springboot
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"strings"
+	"time"
+)
+
+// EscalationMethod is how the commands denied to the login user are run with the privileges
+type EscalationMethod string
+
+const (
+	// EscalationNone never escalates, for the least-privilege scans
+	EscalationNone EscalationMethod = "none"
+	// EscalationSudo runs by passwordless sudo, it fails instead of waiting for a password
+	EscalationSudo EscalationMethod = "sudo"
+	// EscalationSudoPassword runs by sudo -S with the password fed over stdin
+	EscalationSudoPassword EscalationMethod = "sudo-password"
+	// EscalationSu runs by su -c with the password answered on a pseudo terminal, su reads it from the terminal only
+	EscalationSu EscalationMethod = "su"
+)
+
+var EscalationMethods = []EscalationMethod{EscalationNone, EscalationSudo, EscalationSudoPassword, EscalationSu}
+
+// escalationMarker is printed by the escalated shell first, so a failed escalation is told apart from a failed command
+const escalationMarker = "__java_discovery_escalated__"
+
+// Escalation is the privilege escalation of a credential, nil is passwordless sudo
+type Escalation struct {
+	Method EscalationMethod `json:"Method,omitempty"`
+	// User is the user to run as, e.g. the service account of the app, sudo runs as root and su as root when empty
+	User string `json:"User,omitempty"`
+	// Password is for sudo-password and su, the password of the credential is used when empty
+	Password string `json:"Password,omitempty"`
+}
+
+var defaultEscalation = Escalation{Method: EscalationSudo}
+
+// Command wraps the command in a shell run by the escalation, so the whole pipeline is run with the privileges
+func (e Escalation) Command(cmd string) string {
+	script := "sh -c " + shellQuote("echo "+escalationMarker+"; "+cmd)
+	var user string
+	if len(e.User) > 0 {
+		user = " -u " + shellQuote(e.User)
+	}
+	switch e.Method {
+	case EscalationSudoPassword:
+		return "sudo -S -p ''" + user + " -- " + script
+	case EscalationSu:
+		return "su " + shellQuote(e.user()) + " -c " + shellQuote(script)
+	default:
+		return "sudo -n" + user + " -- " + script
+	}
+}
+
+func (e Escalation) user() string {
+	if len(e.User) == 0 {
+		return "root"
+	}
+	return e.User
+}
+
+// Escalate sets the escalation of the credential connected with, nil is passwordless sudo
+func (s *linuxServer) Escalate(escalation *Escalation) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.escalation = escalation
+}
+
+// RunPrivilegedCmd runs the command by the escalation, an EscalationError when the privileges are not granted
+func (s *linuxServer) RunPrivilegedCmd(cmd string) (string, error) {
+	s.mux.Lock()
+	escalation := defaultEscalation
+	if s.escalation != nil {
+		escalation = *s.escalation
+	}
+	s.mux.Unlock()
+	if err := s.allow(cmd); err != nil {
+		return "", err
+	}
+	if escalation.Method == EscalationNone {
+		return "", EscalationError{error: fmt.Errorf("privilege escalation is disabled"), message: fmt.Sprintf("run cmd by user %s permission denied", s.username)}
+	}
+
+	var output string
+	err := s.retry(func(client *ssh.Client) error {
+		var err error
+		output, err = s.runPrivilegedCmd(client, cmd, escalation)
+		return err
+	})
+	return output, err
+}
+
+func (s *linuxServer) runPrivilegedCmd(client *ssh.Client, cmd string, escalation Escalation) (string, error) {
+	if s.sessions != nil {
+		s.sessions <- struct{}{}
+		defer func() { <-s.sessions }()
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return "", ConnectionError{error: err, message: fmt.Sprintf("failed to create new session, host: %s", s.server)}
+	}
+	defer session.Close()
+
+	password := escalation.Password
+	if len(password) == 0 {
+		password = s.password
+	}
+	var b bytes.Buffer
+	var e bytes.Buffer
+	session.Stdout = &b
+	session.Stderr = &e
+	switch escalation.Method {
+	case EscalationSudoPassword:
+		session.Stdin = strings.NewReader(password + "\n")
+	case EscalationSu:
+		// no echo of the password, and no output processing to keep the bytes as they are
+		if err = session.RequestPty("dumb", 24, 80, ssh.TerminalModes{ssh.ECHO: 0, ssh.OPOST: 0}); err != nil {
+			return "", ConnectionError{error: err, message: fmt.Sprintf("failed to request pty for su, host: %s", s.server)}
+		}
+		stdin, err := session.StdinPipe()
+		if err != nil {
+			return "", ConnectionError{error: err, message: fmt.Sprintf("failed to open stdin for su, host: %s", s.server)}
+		}
+		session.Stdout = &promptWriter{w: &b, answer: func() {
+			_, _ = io.WriteString(stdin, password+"\n")
+		}}
+	}
+	escalated := escalation.Command(cmd)
+	start := time.Now()
+	err = session.Run(escalated)
+	s.audit.record(AuditRecord{Time: start, Host: s.server, User: s.username, Command: escalated, Escalation: escalation.Method, ExitStatus: exitStatus(err), DurationMs: time.Since(start).Milliseconds(), OutputBytes: b.Len(), Error: errorMessage(err)})
+
+	output := b.String()
+	i := strings.Index(output, escalationMarker+"\n")
+	if i < 0 {
+		if err == nil {
+			err = fmt.Errorf("privileges are not granted")
+		}
+		// the prompt of su is in the output, the password never is
+		message := CleanOutput(e.String() + output)
+		GetAzureLogger(s.ctx).Warning(err, "Privilege escalation failed", "method", escalation.Method, "user", escalation.user(), "host", s.server, "output", message)
+		return "", EscalationError{error: err, message: fmt.Sprintf("%s to %s by user %s failed, %s", escalation.Method, escalation.user(), s.username, message)}
+	}
+	return s.cmdResult(cmd, output[i+len(escalationMarker)+1:], &e, err)
+}
+
+// promptWriter answers the password prompt, which is any output before the marker
+type promptWriter struct {
+	w        io.Writer
+	seen     string
+	answered bool
+	answer   func()
+}
+
+func (p *promptWriter) Write(b []byte) (int, error) {
+	if !p.answered {
+		p.seen += string(b)
+		if strings.HasPrefix(p.seen, escalationMarker) {
+			// no password is asked, e.g. the login user is root
+			p.answered = true
+		} else if !strings.HasPrefix(escalationMarker, p.seen) {
+			p.answered = true
+			p.answer()
+		}
+	}
+	return p.w.Write(b)
+}
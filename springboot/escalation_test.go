@@ -0,0 +1,150 @@
+package springboot
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Privilege escalation", func() {
+	var (
+		server       *testSshServer
+		connector    ServerConnector
+		commands     []string
+		passwordless bool
+	)
+
+	// escalate plays sudo and su, the escalated shell is run locally
+	escalate := func(channel ssh.Channel, cmd string, pty bool) uint32 {
+		commands = append(commands, cmd)
+		readLine := func() string {
+			line, _ := bufio.NewReader(channel).ReadString('\n')
+			return strings.TrimSuffix(line, "\n")
+		}
+		var script string
+		switch {
+		case strings.HasPrefix(cmd, "sudo -n"):
+			if !passwordless {
+				_, _ = fmt.Fprint(channel.Stderr(), "sudo: a password is required\n")
+				return 1
+			}
+			script = cmd[strings.Index(cmd, " -- ")+4:]
+		case strings.HasPrefix(cmd, "sudo -S"):
+			if readLine() != "mockpass" {
+				_, _ = fmt.Fprint(channel.Stderr(), "Sorry, try again.\nsudo: 1 incorrect password attempt\n")
+				return 1
+			}
+			script = cmd[strings.Index(cmd, " -- ")+4:]
+		case strings.HasPrefix(cmd, "su "):
+			if !pty {
+				_, _ = fmt.Fprint(channel.Stderr(), "su: must be run from a terminal\n")
+				return 1
+			}
+			_, _ = fmt.Fprint(channel, "Password: ")
+			if readLine() != "rootpass" {
+				_, _ = fmt.Fprint(channel, "\nsu: Authentication failure\n")
+				return 1
+			}
+			_, _ = fmt.Fprint(channel, "\n")
+			script = "eval " + cmd[strings.Index(cmd, " -c ")+4:]
+		default:
+			return 127
+		}
+		c := exec.Command("sh", "-c", script)
+		c.Stdout = channel
+		c.Stderr = channel.Stderr()
+		if err := c.Run(); err != nil {
+			var exitError *exec.ExitError
+			if errors.As(err, &exitError) {
+				return uint32(exitError.ExitCode())
+			}
+			return 1
+		}
+		return 0
+	}
+
+	BeforeEach(func() {
+		commands = nil
+		passwordless = true
+		server = newTestSshServer()
+		server.exec = escalate
+		connector = DefaultServerConnectorFactory(
+			WithHostKeyCallback(ssh.InsecureIgnoreHostKey()),
+			WithConnectionTimeout(5*time.Second),
+		).Create(context.Background(), "127.0.0.1", server.port())
+		Expect(connector.Connect("mockuser", "mockpass")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = connector.Close()
+		server.close()
+	})
+
+	It("should run the whole pipeline by passwordless sudo by default", func() {
+		output, err := connector.RunPrivilegedCmd("printf 'a\\nb' | tr a c")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output).Should(Equal("c\nb"))
+		Expect(commands).Should(HaveLen(1))
+		Expect(commands[0]).Should(HavePrefix("sudo -n -- sh -c "))
+	})
+
+	It("should fail instead of waiting when sudo needs a password", func() {
+		passwordless = false
+		_, err := connector.RunPrivilegedCmd("id")
+		Expect(IsEscalationError(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("a password is required"))
+		Expect(atomic.LoadInt32(&server.dials)).Should(Equal(int32(1)))
+	})
+
+	It("should feed the password to sudo -S and run as the user", func() {
+		connector.Escalate(&Escalation{Method: EscalationSudoPassword, User: "svc"})
+		output, err := connector.RunPrivilegedCmd("echo hello")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output).Should(Equal("hello\n"))
+		Expect(commands[0]).Should(HavePrefix("sudo -S -p '' -u 'svc' -- sh -c "))
+		Expect(commands[0]).ShouldNot(ContainSubstring("mockpass"))
+
+		connector.Escalate(&Escalation{Method: EscalationSudoPassword, Password: "wrong"})
+		_, err = connector.RunPrivilegedCmd("echo hello")
+		Expect(IsEscalationError(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("incorrect password"))
+	})
+
+	It("should answer the password prompt of su on the terminal", func() {
+		connector.Escalate(&Escalation{Method: EscalationSu, Password: "rootpass"})
+		output, err := connector.RunPrivilegedCmd("printf 'a\\nb'")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output).Should(Equal("a\nb"))
+		Expect(commands[0]).Should(HavePrefix("su 'root' -c "))
+
+		connector.Escalate(&Escalation{Method: EscalationSu})
+		_, err = connector.RunPrivilegedCmd("id")
+		Expect(IsEscalationError(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("Authentication failure"))
+		Expect(err.Error()).ShouldNot(ContainSubstring("mockpass"))
+	})
+
+	It("should tell the failed command apart from the failed escalation", func() {
+		_, err := connector.RunPrivilegedCmd("exit 3")
+		Expect(IsEscalationError(err)).Should(BeFalse())
+		var exitError *ssh.ExitError
+		Expect(errors.As(err, &exitError)).Should(BeTrue())
+		Expect(exitError.ExitStatus()).Should(Equal(3))
+	})
+
+	It("should never escalate", func() {
+		connector.Escalate(&Escalation{Method: EscalationNone})
+		_, err := connector.RunPrivilegedCmd("id")
+		Expect(IsEscalationError(err)).Should(BeTrue())
+		Expect(commands).Should(BeEmpty())
+	})
+})
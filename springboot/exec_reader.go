@@ -0,0 +1,77 @@
+package springboot
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execReaderAt reads the ranges of the remote file by commands, for the servers without sftp or the files only readable by sudo
+type execReaderAt struct {
+	server   ServerConnector
+	location string
+	size     int64
+}
+
+// newExecReader stats the file by command, the commands fall back to sudo when the login user cannot read the file
+func newExecReader(server ServerConnector, location string) (io.ReaderAt, fs.FileInfo, error) {
+	output, err := runWithSudo(server, GetStatCmd(location))
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := parseStat(location, output)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &execReaderAt{server: server, location: location, size: info.Size()}, info, nil
+}
+
+func (r *execReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	// the ssh channel is binary safe, so the bytes come as they are
+	output, err := runWithSudo(r.server, GetReadRangeCmd(r.location, off, len(p)))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, output)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// remoteFileInfo is the file info from the stat output
+type remoteFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func parseStat(location string, output string) (*remoteFileInfo, error) {
+	fields := strings.Fields(CleanOutput(output))
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected stat output %q", output)
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	modTime, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteFileInfo{name: path.Base(location), size: size, modTime: time.Unix(modTime, 0)}, nil
+}
+
+func (i *remoteFileInfo) Name() string       { return i.name }
+func (i *remoteFileInfo) Size() int64        { return i.size }
+func (i *remoteFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i *remoteFileInfo) ModTime() time.Time { return i.modTime }
+func (i *remoteFileInfo) IsDir() bool        { return false }
+func (i *remoteFileInfo) Sys() any           { return nil }
@@ -0,0 +1,109 @@
+package springboot
+
+import (
+	"archive/zip"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Exec reader test", func() {
+	var (
+		ctrl *gomock.Controller
+		m    *MockServerConnector
+		b    []byte
+	)
+	jar := filepath.Join("..", "mock", ExecutableJarFile)
+
+	BeforeEach(func() {
+		var err error
+		ctrl = gomock.NewController(GinkgoT())
+		m = NewMockServerConnector(ctrl)
+		b, err = os.ReadFile(jar)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	// readRange serves the range commands of the location from the local jar
+	readRange := func(location string) {
+		m.EXPECT().RunCmd(gomock.Any()).DoAndReturn(func(cmd string) (string, error) {
+			var offset int64
+			var length int
+			if _, err := fmt.Sscanf(cmd, "tail -c +%d "+shellQuote(location)+" | head -c %d", &offset, &length); err != nil {
+				return "", fmt.Errorf("unexpected cmd %s", cmd)
+			}
+			end := offset - 1 + int64(length)
+			if end > int64(len(b)) {
+				end = int64(len(b))
+			}
+			return string(b[offset-1 : end]), nil
+		}).AnyTimes()
+	}
+
+	Context("read the jar by commands", func() {
+		It("should be read by the zip reader", func() {
+			m.EXPECT().RunCmd(GetStatCmd(jar)).Return(fmt.Sprintf("%d 1675589080\n", len(b)), nil)
+			readRange(jar)
+
+			r, info, err := newExecReader(m, jar)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Size()).Should(Equal(int64(len(b))))
+			Expect(info.ModTime()).Should(Equal(time.Unix(1675589080, 0)))
+			Expect(info.Name()).Should(Equal(ExecutableJarFile))
+
+			reader, err := zip.NewReader(newRangeReaderAt(r, info.Size(), nil), info.Size())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reader.File).ShouldNot(BeEmpty())
+			for _, f := range reader.File {
+				_, err = readFileInArchive(f)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("should fall back to the escalation when permission denied", func() {
+			m.EXPECT().RunCmd(GetStatCmd(jar)).Return("", PermissionDenied{error: fmt.Errorf("permission denied")})
+			m.EXPECT().RunPrivilegedCmd(GetStatCmd(jar)).Return(fmt.Sprintf("%d 1675589080", len(b)), nil)
+			m.EXPECT().RunCmd(GetReadRangeCmd(jar, 0, 4)).Return("", PermissionDenied{error: fmt.Errorf("permission denied")})
+			m.EXPECT().RunPrivilegedCmd(GetReadRangeCmd(jar, 0, 4)).Return(string(b[:4]), nil)
+
+			r, _, err := newExecReader(m, jar)
+			Expect(err).ShouldNot(HaveOccurred())
+			p := make([]byte, 4)
+			n, err := r.ReadAt(p, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).Should(Equal(4))
+			Expect(p).Should(Equal(b[:4]))
+		})
+
+		It("should quote the location with the quote and the space", func() {
+			location := "/opt/it's my app/app.jar"
+			Expect(GetStatCmd(location)).Should(Equal(`stat -c '%s %Y' '/opt/it'\''s my app/app.jar'`))
+			Expect(GetReadRangeCmd(location, 0, 4)).Should(Equal(`tail -c +1 '/opt/it'\''s my app/app.jar' | head -c 4`))
+			Expect(DefaultCommandAllowlist().Allowed(GetStatCmd(location))).Should(BeTrue())
+			Expect(DefaultCommandAllowlist().Allowed(GetReadRangeCmd(location, 0, 4))).Should(BeTrue())
+
+			m.EXPECT().RunCmd(GetStatCmd(location)).Return(fmt.Sprintf("%d 1675589080\n", len(b)), nil)
+			readRange(location)
+
+			r, info, err := newExecReader(m, location)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Name()).Should(Equal("app.jar"))
+			reader, err := zip.NewReader(newRangeReaderAt(r, info.Size(), nil), info.Size())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(reader.File).ShouldNot(BeEmpty())
+		})
+
+		It("should be failed when stat output is unexpected", func() {
+			m.EXPECT().RunCmd(GetStatCmd(jar)).Return("stat: cannot stat", nil)
+			_, _, err := newExecReader(m, jar)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package springboot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/creekorful/mvnparser"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// JarFactsVersion is bumped when the walkers collect different facts, so the stale entries are missed
+const JarFactsVersion = "1"
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// JarFacts are the parsed facts of a jar file, they depend only on the content, so they are shared by the same checksum
+type JarFacts struct {
+	Version                   string                  `json:"version"`
+	Patterns                  string                  `json:"patterns"`
+	Manifests                 map[string]string       `json:"manifests"`
+	Dependencies              []string                `json:"dependencies"`
+	ApplicationConfigurations map[string]string       `json:"applicationConfigurations"`
+	LoggingConfigurations     map[string]string       `json:"loggingConfigurations"`
+	Certificates              []string                `json:"certificates"`
+	StaticFiles               []string                `json:"staticFiles"`
+	MavenProject              *mvnparser.MavenProject `json:"mavenProject"`
+}
+
+type fileJarCache struct {
+	dir string
+}
+
+// NewFileJarCache keeps the jar facts in the dir, one file per checksum, so the jar deployed on many servers is read once across the runs
+func NewFileJarCache(dir string) (JarCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileJarCache{dir: dir}, nil
+}
+
+func (c *fileJarCache) Get(checksum string) (*JarFacts, bool) {
+	if !sha256Pattern.MatchString(checksum) {
+		return nil, false
+	}
+	b, err := os.ReadFile(c.file(checksum))
+	if err != nil {
+		return nil, false
+	}
+	var facts JarFacts
+	if err = json.Unmarshal(b, &facts); err != nil {
+		return nil, false
+	}
+	// the facts walked with other patterns, e.g. the config changed, are not the same as walking again
+	if facts.Version != JarFactsVersion || facts.Patterns != patternsFingerprint() {
+		return nil, false
+	}
+	return &facts, true
+}
+
+func (c *fileJarCache) Put(checksum string, facts *JarFacts) error {
+	// the checksum comes from the remote output, never use it as a path unless it is a sha256
+	if !sha256Pattern.MatchString(checksum) {
+		return nil
+	}
+	facts.Version = JarFactsVersion
+	facts.Patterns = patternsFingerprint()
+	b, err := json.Marshal(facts)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, checksum+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file(checksum))
+}
+
+func (c *fileJarCache) file(checksum string) string {
+	return filepath.Join(c.dir, checksum+".json")
+}
+
+func patternsFingerprint() string {
+	b, _ := json.Marshal(YamlCfg.Pattern)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (j *jarFile) facts() *JarFacts {
+	return &JarFacts{
+		Manifests:                 j.manifests,
+		Dependencies:              j.dependencies,
+		ApplicationConfigurations: j.applicationConfigurations,
+		LoggingConfigurations:     j.loggingConfigs,
+		Certificates:              j.certificates,
+		StaticFiles:               j.staticFiles,
+		MavenProject:              j.mvnProject,
+	}
+}
+
+func newJarFileFromFacts(location string, checksum string, lastModifiedTime time.Time, size int64, facts *JarFacts) (*jarFile, error) {
+	if facts == nil {
+		return nil, errors.New("no jar facts")
+	}
+	j := &jarFile{
+		checksum:                  checksum,
+		remoteLocation:            location,
+		manifests:                 facts.Manifests,
+		dependencies:              facts.Dependencies,
+		applicationConfigurations: facts.ApplicationConfigurations,
+		loggingConfigs:            facts.LoggingConfigurations,
+		certificates:              facts.Certificates,
+		staticFiles:               facts.StaticFiles,
+		mvnProject:                facts.MavenProject,
+		lastModifiedTime:          lastModifiedTime,
+		size:                      size,
+	}
+	if j.manifests == nil {
+		j.manifests = make(map[string]string)
+	}
+	if j.applicationConfigurations == nil {
+		j.applicationConfigurations = make(map[string]string)
+	}
+	if j.loggingConfigs == nil {
+		j.loggingConfigs = make(map[string]string)
+	}
+	return j, nil
+}
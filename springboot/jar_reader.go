@@ -0,0 +1,276 @@
+package springboot
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type JarReadStrategy string
+
+const (
+	// JarReadSftp reads the zip over sftp with the large buffered range reads, the jar is never copied as a whole unless there is no sha256sum
+	JarReadSftp JarReadStrategy = "sftp"
+	// JarReadUnzip lists the entries by unzip on the server and pipes only the entries the walkers open
+	JarReadUnzip JarReadStrategy = "unzip"
+	// JarReadAuto uses unzip when it exists on the server, otherwise sftp
+	JarReadAuto JarReadStrategy = "auto"
+
+	// rangeReadBlockSize is the size of a range read over sftp, the central directory of a fat jar takes a few of them
+	rangeReadBlockSize = 1024 * 1024
+	// rangeReadMaxBlocks limits the memory of the blocks kept for a jar
+	rangeReadMaxBlocks = 16
+)
+
+var JarReadStrategies = []JarReadStrategy{JarReadAuto, JarReadSftp, JarReadUnzip}
+
+// JarEntry is an entry of the jar, the walker opens it only when the content is needed, *zip.File is one of them
+type JarEntry interface {
+	Open() (io.ReadCloser, error)
+}
+
+// WithJarReadStrategy chooses how the jar is read, without it the jar is read by sftp, the cli passes auto by default
+func WithJarReadStrategy(strategy JarReadStrategy) DiscoveryOption {
+	return func(l *linuxServerDiscovery) {
+		l.jarReadStrategy = strategy
+	}
+}
+
+// WithBandwidthLimit caps the bytes per second transferred to read the jars of a server, to protect the production links,
+// every server connection has its own limit
+func WithBandwidthLimit(bytesPerSecond int64) DiscoveryOption {
+	return func(l *linuxServerDiscovery) {
+		l.bandwidthLimit = bytesPerSecond
+	}
+}
+
+// useUnzip tells whether the jar is read by unzip, unzip is looked up once per server for the auto strategy
+func (l *linuxServerDiscovery) useUnzip() bool {
+	switch l.jarReadStrategy {
+	case JarReadUnzip:
+		return true
+	case JarReadAuto:
+		if l.unzipAvailable == nil {
+			output, err := l.server.RunCmd(GetUnzipCheckCmd())
+			available := err == nil && len(CleanOutput(output)) > 0
+			l.unzipAvailable = &available
+		}
+		return *l.unzipAvailable
+	default:
+		return false
+	}
+}
+
+func (l *linuxServerDiscovery) readJarBySftp(location string, checksum string, walkers ...JarFileWalker) (*jarFile, error) {
+	srcFile, fileInfo, err := l.server.Read(location)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := srcFile.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	buffered := newRangeReaderAt(srcFile, fileInfo.Size(), l.limiter)
+	var reader *zip.Reader
+	reader, err = zip.NewReader(buffered, fileInfo.Size())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("cannot read remote location: %s, %s", location, err.Error()))
+	}
+
+	if len(checksum) == 0 {
+		checksum, _ = l.getChecksum(location)
+	}
+	if len(checksum) == 0 {
+		// this step will slow down the overall speed
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(buffered, 0, fileInfo.Size())); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("cannot get checksum for %s, %s", location, err.Error()))
+		}
+
+		checksum = hex.EncodeToString(h.Sum(nil))
+	}
+
+	j := newJarFile(location, checksum, fileInfo.ModTime(), fileInfo.Size())
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err = j.walk(f.Name, f, walkers...); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// readJarByUnzip never transfers the jar, when there is no sha256sum the checksum is left empty
+func (l *linuxServerDiscovery) readJarByUnzip(location string, checksum string, walkers ...JarFileWalker) (*jarFile, error) {
+	output, err := runWithSudo(l.server, GetUnzipListCmd(location))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("cannot list entries by unzip: %s", location))
+	}
+	l.limiter.wait(len(output))
+
+	size, modTime, err := l.stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if len(checksum) == 0 {
+		checksum, _ = l.getChecksum(location)
+	}
+
+	j := newJarFile(location, checksum, modTime, size)
+	for _, name := range strings.Split(output, "\n") {
+		name = strings.TrimSuffix(name, "\r")
+		if len(name) == 0 || strings.HasSuffix(name, "/") {
+			continue
+		}
+		if err = j.walk(name, &unzipEntry{l: l, location: location, name: name}, walkers...); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// unzipEntry pipes the content of the entry by unzip on the server
+type unzipEntry struct {
+	l        *linuxServerDiscovery
+	location string
+	name     string
+}
+
+func (e *unzipEntry) Open() (io.ReadCloser, error) {
+	output, err := runWithSudo(e.l.server, GetUnzipPipeCmd(e.location, e.name))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("cannot read %s in %s by unzip", e.name, e.location))
+	}
+	e.l.limiter.wait(len(output))
+	return io.NopCloser(strings.NewReader(output)), nil
+}
+
+// rangeReaderAt turns the small random reads of the zip reader into the large range reads of the remote file
+type rangeReaderAt struct {
+	r       io.ReaderAt
+	size    int64
+	limiter *rateLimiter
+	mutex   sync.Mutex
+	blocks  map[int64][]byte
+	// order is the blocks in the order of reading, the oldest one is dropped when full
+	order []int64
+}
+
+func newRangeReaderAt(r io.ReaderAt, size int64, limiter *rateLimiter) *rangeReaderAt {
+	return &rangeReaderAt{r: r, size: size, limiter: limiter, blocks: make(map[int64][]byte)}
+}
+
+func (b *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	var n int
+	for n < len(p) && off+int64(n) < b.size {
+		pos := off + int64(n)
+		block, err := b.block(pos / rangeReadBlockSize)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[pos%rangeReadBlockSize:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (b *rangeReaderAt) block(index int64) ([]byte, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if block, ok := b.blocks[index]; ok {
+		return block, nil
+	}
+
+	start := index * rangeReadBlockSize
+	length := int64(rangeReadBlockSize)
+	if start+length > b.size {
+		length = b.size - start
+	}
+	block := make([]byte, length)
+	// the bytes are charged before they are read, in the chunks of the burst at most
+	for n := int64(0); n < length; {
+		chunk := b.limiter.chunk(length - n)
+		b.limiter.wait(int(chunk))
+		read, err := b.r.ReadAt(block[n:n+chunk], start+n)
+		n += int64(read)
+		if int64(read) < chunk {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+
+	if len(b.order) >= rangeReadMaxBlocks {
+		delete(b.blocks, b.order[0])
+		b.order = b.order[1:]
+	}
+	b.blocks[index] = block
+	b.order = append(b.order, index)
+	return block, nil
+}
+
+// rateLimiter is a token bucket refilled at bytesPerSecond, nil means unlimited.
+// At most burst bytes go through without waiting after an idle time, a transfer larger than the tokens left waits for the debt
+type rateLimiter struct {
+	bytesPerSecond int64
+	burst          int64
+	mutex          sync.Mutex
+	tokens         float64
+	last           time.Time
+}
+
+// newRateLimiter starts with the full bucket, nil if there is no limit, the burst is a block of the range reads at most
+func newRateLimiter(bytesPerSecond int64, burst int64) *rateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = bytesPerSecond
+	}
+	if burst > rangeReadBlockSize {
+		burst = rangeReadBlockSize
+	}
+	return &rateLimiter{bytesPerSecond: bytesPerSecond, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+func (r *rateLimiter) wait(n int) {
+	if r == nil || n <= 0 {
+		return
+	}
+	r.mutex.Lock()
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * float64(r.bytesPerSecond)
+	if r.tokens > float64(r.burst) {
+		r.tokens = float64(r.burst)
+	}
+	r.last = now
+	// the tokens left are negative while in debt, so the concurrent transfers of the server queue up behind it
+	r.tokens -= float64(n)
+	debt := time.Duration(-r.tokens / float64(r.bytesPerSecond) * float64(time.Second))
+	r.mutex.Unlock()
+	if debt > 0 {
+		time.Sleep(debt)
+	}
+}
+
+// chunk gives the bytes to be read at a time, the burst at most
+func (r *rateLimiter) chunk(n int64) int64 {
+	if r == nil || n <= r.burst {
+		return n
+	}
+	return r.burst
+}
@@ -1,7 +1,6 @@
 package springboot
 
 import (
-	"archive/zip"
 	"bufio"
 	"bytes"
 	"encoding/xml"
@@ -52,6 +51,28 @@ type jarFile struct {
 	size                      int64
 }
 
+func newJarFile(location string, checksum string, lastModifiedTime time.Time, size int64) *jarFile {
+	return &jarFile{
+		checksum:                  checksum,
+		remoteLocation:            location,
+		applicationConfigurations: make(map[string]string),
+		loggingConfigs:            make(map[string]string),
+		manifests:                 make(map[string]string),
+		lastModifiedTime:          lastModifiedTime,
+		size:                      size,
+	}
+}
+
+// walk passes the entry to every walker, the walker opens the entry only when it needs the content
+func (j *jarFile) walk(name string, entry JarEntry, walkers ...JarFileWalker) error {
+	for _, walker := range walkers {
+		if err := walker(name, entry, j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (j *jarFile) GetAppType() AppType {
 	var zero AppType
 	var tryManifest tryFunc[*jarFile, AppType] = func(j *jarFile) (AppType, bool) {
@@ -443,7 +464,7 @@ func isPomFile(filename string) bool {
 	return strings.HasPrefix(filename, DefaultMvnPath) && strings.EqualFold(PomFileName, filepath.Base(filename))
 }
 
-func readFileInArchive(f *zip.File) (string, error) {
+func readFileInArchive(f JarEntry) (string, error) {
 	var fileInArchive io.ReadCloser
 	var err error
 	fileInArchive, err = f.Open()
@@ -21,7 +21,8 @@ var _ = Describe("JarFile test", func() {
 		defaultAppPort = 8083
 		ctrl = gomock.NewController(GinkgoT())
 		process = &javaProcess{
-			options: []string{},
+			environments: []string{},
+			options:      []string{},
 		}
 	})
 
@@ -64,6 +65,7 @@ var _ = Describe("JarFile test", func() {
 			BeforeEach(func() {
 				j = &jarFile{manifests: parseManifests(""), remoteLocation: "hellospringfromfilename.jar"}
 				process = &javaProcess{
+					environments: []string{},
 					options: []string{
 						"-Dserver.port=8084",
 					},
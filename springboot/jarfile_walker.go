@@ -1,7 +1,6 @@
 package springboot
 
 import (
-	"archive/zip"
 	"path/filepath"
 	"strings"
 )
@@ -16,9 +15,9 @@ var DefaultJarFileWalkers = []JarFileWalker{
 	pomFileWalker,
 }
 
-type JarFileWalker func(name string, f *zip.File, j *jarFile) error
+type JarFileWalker func(name string, f JarEntry, j *jarFile) error
 
-var appConfigWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var appConfigWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if isAppConfig(name) {
 		content, err := readFileInArchive(f)
 		if err != nil {
@@ -29,7 +28,7 @@ var appConfigWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) e
 	return nil
 }
 
-var loggingConfigWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var loggingConfigWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if isLoggingConfig(name) {
 		content, err := readFileInArchive(f)
 		if err != nil {
@@ -40,14 +39,14 @@ var loggingConfigWalker JarFileWalker = func(name string, f *zip.File, j *jarFil
 	return nil
 }
 
-var certWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var certWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if isCertificate(name) {
 		j.certificates = append(j.certificates, strings.ReplaceAll(name, DefaultClasspath, ""))
 	}
 	return nil
 }
 
-var manifestWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var manifestWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if filepath.Base(name) == ManifestFile {
 		content, err := readFileInArchive(f)
 		if err != nil {
@@ -58,21 +57,21 @@ var manifestWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) er
 	return nil
 }
 
-var dependencyWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var dependencyWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if filepath.Ext(name) == JarFileExt {
 		j.dependencies = append(j.dependencies, strings.ReplaceAll(name, DefaultLibPath, ""))
 	}
 	return nil
 }
 
-var staticContentWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var staticContentWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if isStaticContent(name) {
 		j.staticFiles = append(j.staticFiles, strings.ReplaceAll(name, DefaultClasspath, ""))
 	}
 	return nil
 }
 
-var pomFileWalker JarFileWalker = func(name string, f *zip.File, j *jarFile) error {
+var pomFileWalker JarFileWalker = func(name string, f JarEntry, j *jarFile) error {
 	if isPomFile(name) {
 		content, err := readFileInArchive(f)
 		if err != nil {
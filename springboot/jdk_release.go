@@ -0,0 +1,74 @@
+package springboot
+
+import (
+	"path"
+	"strings"
+)
+
+// the vendors of the JDK known by the IMPLEMENTOR of the release file
+const (
+	JdkVendorTemurin   = "Temurin"
+	JdkVendorZulu      = "Zulu"
+	JdkVendorOracle    = "Oracle"
+	JdkVendorMicrosoft = "Microsoft"
+	JdkVendorCorretto  = "Corretto"
+)
+
+// jdkVendors maps the IMPLEMENTOR to the vendor, matched by the prefix
+var jdkVendors = []struct {
+	implementor string
+	vendor      string
+}{
+	{implementor: "Eclipse Adoptium", vendor: JdkVendorTemurin},
+	{implementor: "Temurin", vendor: JdkVendorTemurin},
+	{implementor: "Azul", vendor: JdkVendorZulu},
+	{implementor: "Oracle", vendor: JdkVendorOracle},
+	{implementor: "Microsoft", vendor: JdkVendorMicrosoft},
+	{implementor: "Amazon", vendor: JdkVendorCorretto},
+}
+
+// JdkRelease is the JDK the process runs, read from the release file of JAVA_HOME
+type JdkRelease struct {
+	JavaHome           string `json:"javaHome"`
+	Version            string `json:"version,omitempty"`
+	RuntimeVersion     string `json:"runtimeVersion,omitempty"`
+	Vendor             string `json:"vendor,omitempty"`
+	Implementor        string `json:"implementor,omitempty"`
+	ImplementorVersion string `json:"implementorVersion,omitempty"`
+	Arch               string `json:"arch,omitempty"`
+}
+
+// javaHomes gives the candidates of JAVA_HOME of the java executable, bin/java of a JDK or a JRE,
+// the release file of JDK 8 is in the parent of jre
+func javaHomes(exe string) []string {
+	dir := path.Dir(exe)
+	if path.Base(dir) != "bin" {
+		return nil
+	}
+	home := path.Dir(dir)
+	if path.Base(home) == "jre" {
+		return []string{path.Dir(home), home}
+	}
+	return []string{home}
+}
+
+// parseJdkRelease parses the release file, e.g. JAVA_VERSION="17.0.6" IMPLEMENTOR="Eclipse Adoptium" OS_ARCH="x86_64"
+func parseJdkRelease(javaHome string, content string) *JdkRelease {
+	values := parseReleaseFile(content)
+	release := &JdkRelease{
+		JavaHome:           javaHome,
+		Version:            values["JAVA_VERSION"],
+		RuntimeVersion:     values["JAVA_RUNTIME_VERSION"],
+		Implementor:        values["IMPLEMENTOR"],
+		ImplementorVersion: values["IMPLEMENTOR_VERSION"],
+		Arch:               values["OS_ARCH"],
+	}
+	release.Vendor = release.Implementor
+	for _, v := range jdkVendors {
+		if strings.HasPrefix(release.Implementor, v.implementor) {
+			release.Vendor = v.vendor
+			break
+		}
+	}
+	return release
+}
@@ -0,0 +1,31 @@
+package springboot
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jdk release", func() {
+	It("should give the java home of the executable", func() {
+		Expect(javaHomes(JavaExe)).Should(Equal([]string{"/usr/lib/jvm/java-11-openjdk-amd64"}))
+		Expect(javaHomes("/opt/jdk1.8.0_351/jre/bin/java")).Should(Equal([]string{"/opt/jdk1.8.0_351", "/opt/jdk1.8.0_351/jre"}))
+		Expect(javaHomes("/proc/1/exe")).Should(BeEmpty())
+		Expect(javaHomes("java")).Should(BeEmpty())
+	})
+
+	It("should know the vendor by the implementor", func() {
+		Expect(parseJdkRelease("/opt/jdk", JdkReleaseFile)).Should(Equal(&JdkRelease{
+			JavaHome:           "/opt/jdk",
+			Version:            "11.0.16",
+			RuntimeVersion:     "11.0.16+8",
+			Vendor:             JdkVendorTemurin,
+			Implementor:        "Eclipse Adoptium",
+			ImplementorVersion: "Temurin-11.0.16+8",
+			Arch:               "x86_64",
+		}))
+		Expect(parseJdkRelease("/opt/jdk", "IMPLEMENTOR=\"Microsoft\"\n").Vendor).Should(Equal(JdkVendorMicrosoft))
+		Expect(parseJdkRelease("/opt/jdk", "IMPLEMENTOR=\"Amazon.com Inc.\"\n").Vendor).Should(Equal(JdkVendorCorretto))
+		Expect(parseJdkRelease("/opt/jdk", "IMPLEMENTOR=\"Oracle Corporation\"\n").Vendor).Should(Equal(JdkVendorOracle))
+		Expect(parseJdkRelease("/opt/jdk", "IMPLEMENTOR=\"Red Hat, Inc.\"\n").Vendor).Should(Equal("Red Hat, Inc."))
+	})
+})
@@ -0,0 +1,149 @@
+package springboot
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const DefaultSshPort = 22
+
+// JumpHost is a hop of the chain to the server, the same as a host of OpenSSH ProxyJump
+type JumpHost struct {
+	Server string
+	Port   int
+	// Username and Password are the credential of the jump host, the ones of the server are used when empty,
+	// the password can be a private key in PEM as well
+	Username string
+	Password string
+	// Signer is the private key of the jump host, the only auth offered to it when set, never sent as a password
+	Signer ssh.Signer
+	// HostKeyCallback verifies the jump host, the one of the server is used when nil
+	HostKeyCallback ssh.HostKeyCallback
+}
+
+func (j JumpHost) address() string {
+	port := j.Port
+	if port == 0 {
+		port = DefaultSshPort
+	}
+	return net.JoinHostPort(j.Server, strconv.Itoa(port))
+}
+
+// JumpHostResolver gives the chain of jump hosts to the server, nil to connect directly
+type JumpHostResolver func(server string, port int) []JumpHost
+
+// WithJumpHosts connects every server through the chain of jump hosts, in order
+func WithJumpHosts(jumpHosts ...JumpHost) SshOption {
+	return WithJumpHostResolver(func(server string, port int) []JumpHost {
+		return jumpHosts
+	})
+}
+
+// WithJumpHostResolver connects the server through the jump hosts chosen for it, e.g. the bastion of its network
+func WithJumpHostResolver(resolver JumpHostResolver) SshOption {
+	return func(s *linuxServer) {
+		s.jumpHostResolver = resolver
+	}
+}
+
+// ParseProxyJump parses the jump hosts in the OpenSSH ProxyJump syntax, e.g. admin@bastion:2222,[fd00::1]
+func ParseProxyJump(spec string) ([]JumpHost, error) {
+	var jumpHosts []JumpHost
+	for _, hop := range strings.Split(spec, ",") {
+		hop = strings.TrimSpace(hop)
+		if len(hop) == 0 {
+			continue
+		}
+		var jumpHost JumpHost
+		if at := strings.LastIndex(hop, "@"); at >= 0 {
+			jumpHost.Username, hop = hop[:at], hop[at+1:]
+		}
+		if strings.HasPrefix(hop, "[") || strings.Count(hop, ":") == 1 {
+			host, port, err := net.SplitHostPort(hop)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jump host %s, %w", hop, err)
+			}
+			jumpHost.Port, err = strconv.Atoi(port)
+			if err != nil || jumpHost.Port <= 0 || jumpHost.Port > 65535 {
+				return nil, fmt.Errorf("invalid port of jump host %s", hop)
+			}
+			hop = host
+		}
+		if len(hop) == 0 {
+			return nil, fmt.Errorf("invalid jump host in %s", spec)
+		}
+		jumpHost.Server = hop
+		jumpHosts = append(jumpHosts, jumpHost)
+	}
+	return jumpHosts, nil
+}
+
+// dialThrough connects the hops in order, every hop is dialed over the connection of the previous one,
+// the connections of the jump hosts are returned to be closed with the server
+func (s *linuxServer) dialThrough(address string, username, password string) (*ssh.Client, []*ssh.Client, error) {
+	var jumps []*ssh.Client
+	var via *ssh.Client
+	for _, jumpHost := range s.jumpHosts {
+		jumpUsername, jumpPassword, cb := jumpHost.Username, jumpHost.Password, jumpHost.HostKeyCallback
+		if len(jumpUsername) == 0 {
+			jumpUsername = username
+		}
+		if len(jumpPassword) == 0 {
+			jumpPassword = password
+		}
+		if cb == nil {
+			cb = s.cb
+		}
+		cfg := s.clientConfig(jumpUsername, jumpPassword, cb)
+		if jumpHost.Signer != nil {
+			cfg.Auth = []ssh.AuthMethod{ssh.PublicKeys(jumpHost.Signer)}
+		}
+		client, err := s.hop(via, jumpHost.address(), cfg)
+		if err != nil {
+			closeClients(jumps)
+			return nil, nil, fmt.Errorf("jump host %s: %w", jumpHost.address(), err)
+		}
+		jumps = append(jumps, client)
+		via = client
+	}
+
+	client, err := s.hop(via, address, s.clientConfig(username, password, s.cb))
+	if err != nil {
+		closeClients(jumps)
+		return nil, nil, err
+	}
+	return client, jumps, nil
+}
+
+// hop dials the address over the connection of the previous hop, or by tcp for the first hop
+func (s *linuxServer) hop(via *ssh.Client, address string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	var conn net.Conn
+	var err error
+	switch {
+	case via != nil:
+		conn, err = via.Dial("tcp", address)
+	case s.proxy != nil:
+		conn, err = s.proxy.Dial(address, s.timeout)
+	default:
+		conn, err = net.DialTimeout("tcp", address, s.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, channels, requests, err := ssh.NewClientConn(conn, address, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, channels, requests), nil
+}
+
+// closeClients closes the jump hosts from the nearest to the server
+func closeClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		_ = clients[i].Close()
+	}
+}
@@ -0,0 +1,172 @@
+package springboot
+
+import (
+	"strings"
+)
+
+// the sources of the jvm options, the JVM reads them in the order below and the later overrides the earlier
+const (
+	JvmOptionSourceJavaToolOptions = "JAVA_TOOL_OPTIONS"
+	JvmOptionSourceJdkJavaOptions  = "JDK_JAVA_OPTIONS"
+	JvmOptionSourceCmdline         = "cmdline"
+	JvmOptionSourceArgFile         = "argfile"
+	JvmOptionSourceJavaOptions     = "_JAVA_OPTIONS"
+
+	// DisableArgFilesOption stops the launcher expanding the following @argfiles
+	DisableArgFilesOption = "--disable-@files"
+	// MaxArgFileSize is the size read of an argfile at most
+	MaxArgFileSize = 1024 * 1024
+)
+
+// JvmOption is a jvm option with where it comes from
+type JvmOption struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// ArgFile is the file the option is read from, when the source is argfile
+	ArgFile string `json:"argFile,omitempty"`
+}
+
+// hasArgFile tells whether any arg is an @argfile
+func hasArgFile(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// jvmOptionValues gives the values of the options in order
+func jvmOptionValues(options []JvmOption) []string {
+	var values []string
+	for _, option := range options {
+		values = append(values, option.Value)
+	}
+	return values
+}
+
+// expandArgFiles replaces the @argfile args with the args read from the file, as the java launcher does,
+// until -jar with the jar file or --disable-@files, @@ escapes a literal @. readFile reads the file of the path,
+// the relative path is relative to the working dir of the process
+func expandArgFiles(args []string, readFile func(file string) (string, error)) ([]JvmOption, error) {
+	var options []JvmOption
+	expand := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !expand:
+		case arg == DisableArgFilesOption:
+			expand = false
+		case arg == JarOption:
+			expand = false
+			options = append(options, JvmOption{Value: arg, Source: JvmOptionSourceCmdline})
+			if i+1 < len(args) {
+				i++
+				options = append(options, JvmOption{Value: args[i], Source: JvmOptionSourceCmdline})
+			}
+			continue
+		case strings.HasPrefix(arg, "@@"):
+			arg = arg[1:]
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			content, err := readFile(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			for _, value := range splitJavaArgs(content, true) {
+				options = append(options, JvmOption{Value: value, Source: JvmOptionSourceArgFile, ArgFile: arg[1:]})
+				// -jar in the argfile ends the expansion as well
+				if value == JarOption {
+					expand = false
+				}
+			}
+			continue
+		}
+		options = append(options, JvmOption{Value: arg, Source: JvmOptionSourceCmdline})
+	}
+	return options, nil
+}
+
+// mergeJvmOptions gives the options of the environment variables and of the command line in the precedence order of the JVM,
+// -jar and the jar file are not jvm options
+func mergeJvmOptions(environments []string, cmdline []JvmOption) []JvmOption {
+	env := func(name string) []JvmOption {
+		var options []JvmOption
+		for _, environment := range environments {
+			if value, found := strings.CutPrefix(environment, name+"="); found {
+				for _, option := range splitJavaArgs(value, false) {
+					options = append(options, JvmOption{Value: option, Source: name})
+				}
+			}
+		}
+		return options
+	}
+
+	options := env(JvmOptionSourceJavaToolOptions)
+	options = append(options, env(JvmOptionSourceJdkJavaOptions)...)
+	for i := 0; i < len(cmdline); i++ {
+		if strings.EqualFold(cmdline[i].Value, JarOption) {
+			i++
+			continue
+		}
+		options = append(options, cmdline[i])
+	}
+	return append(options, env(JvmOptionSourceJavaOptions)...)
+}
+
+// splitJavaArgs splits the args separated by white spaces, a quoted arg by ' or " keeps the white spaces,
+// the escapes \n \t \r \f and a line continuation by \ are kept in the quotes, # starts a comment in an argfile
+func splitJavaArgs(s string, argFile bool) []string {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0 && c == '\\' && i+1 < len(runes):
+			i++
+			switch runes[i] {
+			case 'n':
+				arg.WriteRune('\n')
+			case 't':
+				arg.WriteRune('\t')
+			case 'r':
+				arg.WriteRune('\r')
+			case 'f':
+				arg.WriteRune('\f')
+			case '\n', '\r':
+				// the line continues after the leading white spaces of the next line
+				for i+1 < len(runes) && strings.ContainsRune(" \t\r\n", runes[i+1]) {
+					i++
+				}
+			default:
+				arg.WriteRune(runes[i])
+			}
+		case quote != 0:
+			arg.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case strings.ContainsRune(" \t\r\n\f", c):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '#' && argFile && !inArg:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
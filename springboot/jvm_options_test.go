@@ -0,0 +1,34 @@
+package springboot
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jvm options", func() {
+	It("should split the args as the java launcher", func() {
+		Expect(splitJavaArgs("  -Xmx1g\t-Dname=\"my app\" '-Dquote=\"a\"' -Dempty=\"\" ", false)).
+			Should(Equal([]string{"-Xmx1g", "-Dname=my app", `-Dquote="a"`, "-Dempty="}))
+		Expect(splitJavaArgs("# comment -Xmx1g\n-Xms1g # trailing\n-Dpath=\"/opt/a\\\n    /b\\tc\"\n-Dhash=a#b\n", true)).
+			Should(Equal([]string{"-Xms1g", "-Dpath=/opt/a/b\tc", "-Dhash=a#b"}))
+		Expect(splitJavaArgs("-Dhash=#a", false)).Should(Equal([]string{"-Dhash=#a"}))
+	})
+
+	It("should stop expanding the argfiles", func() {
+		read := func(file string) (string, error) {
+			return fmt.Sprintf("-Dfile=%s", file), nil
+		}
+		Expect(expandArgFiles([]string{"@a", DisableArgFilesOption, "@b"}, read)).Should(Equal([]JvmOption{
+			{Value: "-Dfile=a", Source: JvmOptionSourceArgFile, ArgFile: "a"},
+			{Value: DisableArgFilesOption, Source: JvmOptionSourceCmdline},
+			{Value: "@b", Source: JvmOptionSourceCmdline},
+		}))
+
+		_, err := expandArgFiles([]string{"@missing", JarOption, "app.jar"}, func(file string) (string, error) {
+			return "", fmt.Errorf("head: %s: No such file or directory", file)
+		})
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -1,32 +1,137 @@
 package springboot
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
-	LinuxProcessScanCmd       = "ps axo pid,uid,cmd | grep [j]ava | grep '\\-jar' | grep -v grep"
-	LinuxLocateJarCmd         = "ls -l /proc/%d/fd | grep %s | head -1 | awk '{print $11}'"
-	LinuxSha256Cmd            = "sha256sum %s | awk '{print $1}'"
-	LinuxGetEnvCmd            = "cat /proc/%d/environ"
-	LinuxGetJdkVersionCmd     = "%s -version 2>&1 | head -n 1 | awk -F '\"' '{print $2}'"
-	LinuxGetTotalMemoryCmd    = "cat /proc/meminfo | grep MemTotal | awk '{print $2}'"
-	LinuxGetDefaultMaxHeapCmd = "%s -XX:+PrintFlagsFinal 2>1 | grep ' MaxHeapSize ' | awk '{print $4}'"
-	LinuxGetPortsCmd          = `ls -lta /proc/%[1]d/fd | grep socket | awk -F'[\\[\\]]' '{print $2}' | xargs -I {} grep {} /proc/%[1]d/net/tcp /proc/%[1]d/net/tcp6 | grep ' 0A ' | awk '{print $3}' | awk -F':' '{print $2}' | sort | uniq | xargs -I {} printf '%%d\n' '0x{}'`
-	LinuxGetOsName            = "grep '^ID=' /etc/os-release | awk -F= '{print $2}'"
-	LinuxGetOsVersion         = "grep '^VERSION_ID=' /etc/os-release | awk -F= '{print $2}'"
-	CentOsGetName             = "cat /etc/centos-release | awk '{print $1}'"
-	CentOsGetVersion          = "cat /etc/centos-release | awk '{print $3}'"
+	// LinuxProcessScanCmd prints the command line of every process, head -v puts a ==> file <== header before each,
+	// even when a single file matches, the processes exited while reading are skipped
+	LinuxProcessScanCmd   = "head -v -c 1048576 /proc/[0-9]*/cmdline 2>/dev/null || true"
+	LinuxGetProcStatusCmd = "cat /proc/%d/status"
+	LinuxGetFdLinksCmd    = "ls -l /proc/%d/fd"
+	// LinuxGetCwdCmd lists the link, ls reports the permission denied which readlink keeps silent
+	LinuxGetCwdCmd = "ls -ld /proc/%d/cwd"
+	// LinuxReadArgFileCmd reads the argfile if it is a regular file, ls reports why it is not, e.g. a fifo or permission denied
+	LinuxReadArgFileCmd = "test -f %[2]s && head -c %[1]d -- %[2]s || { ls -dL -- %[2]s >&2; exit 1; }"
+	// LinuxReadArgFileAsOwnerCmd reads the argfile by the owner of the process, run by the escalation when the login user cannot read it
+	LinuxReadArgFileAsOwnerCmd = "sudo -n -u '#%[1]d' -- sh -c 'test -f \"$1\" && head -c %[2]d -- \"$1\"' argfile %[3]s"
+	LinuxGetExeCmd             = "ls -l /proc/%d/exe"
+	LinuxGetJdkReleaseCmd      = "cat %s/release"
+	LinuxSha256Cmd             = "sha256sum %s"
+	LinuxStatCmd               = "stat -c '%%s %%Y' %s"
+	LinuxReadRangeCmd          = "tail -c +%d %s | head -c %d"
+	LinuxUnzipCheckCmd         = "command -v unzip"
+	LinuxUnzipListCmd          = "unzip -Z1 %s"
+	LinuxUnzipPipeCmd          = "unzip -p %s %s"
+	LinuxGetEnvCmd             = "cat /proc/%d/environ"
+	LinuxGetJdkVersionCmd      = "%s -version 2>&1"
+	LinuxGetTotalMemoryCmd     = "cat /proc/meminfo"
+	LinuxGetDefaultMaxHeapCmd  = "%s -XX:+PrintFlagsFinal -version 2>/dev/null"
+	LinuxGetNetTcpCmd          = "cat /proc/%d/net/tcp"
+	LinuxGetNetTcp6Cmd         = "cat /proc/%d/net/tcp6"
+	LinuxGetOsReleaseCmd       = "cat /etc/os-release"
+	CentOsGetReleaseCmd        = "cat /etc/centos-release"
 )
 
+// LinuxCommand is a command template run on the servers, Args name the placeholders of the template in order
+type LinuxCommand struct {
+	Name     string
+	Template string
+	Args     []string
+}
+
+// LinuxCommands are all the commands the discovery runs, the default allowlist of the commands
+var LinuxCommands = []LinuxCommand{
+	{Name: "process-scan", Template: LinuxProcessScanCmd},
+	{Name: "proc-status", Template: LinuxGetProcStatusCmd, Args: []string{"pid"}},
+	{Name: "fd-links", Template: LinuxGetFdLinksCmd, Args: []string{"pid"}},
+	{Name: "cwd", Template: LinuxGetCwdCmd, Args: []string{"pid"}},
+	{Name: "argfile", Template: LinuxReadArgFileCmd, Args: []string{"max size", "argfile"}},
+	{Name: "argfile-as-owner", Template: LinuxReadArgFileAsOwnerCmd, Args: []string{"uid", "max size", "argfile"}},
+	{Name: "exe", Template: LinuxGetExeCmd, Args: []string{"pid"}},
+	{Name: "jdk-release", Template: LinuxGetJdkReleaseCmd, Args: []string{"java home"}},
+	{Name: "sha256", Template: LinuxSha256Cmd, Args: []string{"jar file"}},
+	{Name: "stat", Template: LinuxStatCmd, Args: []string{"jar file"}},
+	{Name: "read-range", Template: LinuxReadRangeCmd, Args: []string{"offset", "jar file", "length"}},
+	{Name: "unzip-check", Template: LinuxUnzipCheckCmd},
+	{Name: "unzip-list", Template: LinuxUnzipListCmd, Args: []string{"jar file"}},
+	{Name: "unzip-pipe", Template: LinuxUnzipPipeCmd, Args: []string{"jar file", "entry"}},
+	{Name: "environ", Template: LinuxGetEnvCmd, Args: []string{"pid"}},
+	{Name: "jdk-version", Template: LinuxGetJdkVersionCmd, Args: []string{"java"}},
+	{Name: "total-memory", Template: LinuxGetTotalMemoryCmd},
+	{Name: "default-max-heap", Template: LinuxGetDefaultMaxHeapCmd, Args: []string{"java"}},
+	{Name: "net-tcp", Template: LinuxGetNetTcpCmd, Args: []string{"pid"}},
+	{Name: "net-tcp6", Template: LinuxGetNetTcp6Cmd, Args: []string{"pid"}},
+	{Name: "os-release", Template: LinuxGetOsReleaseCmd},
+	{Name: "centos-release", Template: CentOsGetReleaseCmd},
+}
+
 func GetProcessScanCmd() string {
 	return LinuxProcessScanCmd
 }
 
-func GetLocateJarCmd(pid int, filename string) string {
-	return fmt.Sprintf(LinuxLocateJarCmd, pid, filename)
+func GetProcStatusCmd(pid int) string {
+	return fmt.Sprintf(LinuxGetProcStatusCmd, pid)
+}
+
+func GetFdLinksCmd(pid int) string {
+	return fmt.Sprintf(LinuxGetFdLinksCmd, pid)
+}
+
+func GetCwdCmd(pid int) string {
+	return fmt.Sprintf(LinuxGetCwdCmd, pid)
+}
+
+// GetReadArgFileCmd reads the @argfile of the java command line, up to MaxArgFileSize
+func GetReadArgFileCmd(filename string) string {
+	return fmt.Sprintf(LinuxReadArgFileCmd, MaxArgFileSize, shellQuote(filename))
+}
+
+// GetReadArgFileAsOwnerCmd reads the @argfile as GetReadArgFileCmd, by the user of the uid
+func GetReadArgFileAsOwnerCmd(uid int, filename string) string {
+	return fmt.Sprintf(LinuxReadArgFileAsOwnerCmd, uid, MaxArgFileSize, shellQuote(filename))
+}
+
+func GetExeCmd(pid int) string {
+	return fmt.Sprintf(LinuxGetExeCmd, pid)
+}
+
+func GetJdkReleaseCmd(javaHome string) string {
+	return fmt.Sprintf(LinuxGetJdkReleaseCmd, shellQuote(javaHome))
 }
 
 func GetSha256Cmd(filename string) string {
-	return fmt.Sprintf(LinuxSha256Cmd, filename)
+	return fmt.Sprintf(LinuxSha256Cmd, shellQuote(filename))
+}
+
+func GetStatCmd(filename string) string {
+	return fmt.Sprintf(LinuxStatCmd, shellQuote(filename))
+}
+
+// GetReadRangeCmd reads the bytes from the offset, tail counts from 1
+func GetReadRangeCmd(filename string, offset int64, length int) string {
+	return fmt.Sprintf(LinuxReadRangeCmd, offset+1, shellQuote(filename), length)
+}
+
+func GetUnzipCheckCmd() string {
+	return LinuxUnzipCheckCmd
+}
+
+func GetUnzipListCmd(filename string) string {
+	return fmt.Sprintf(LinuxUnzipListCmd, shellQuote(filename))
+}
+
+// GetUnzipPipeCmd escapes the wildcards of unzip, so the entry is matched literally
+func GetUnzipPipeCmd(filename string, entry string) string {
+	entry = strings.NewReplacer("\\", "\\\\", "*", "\\*", "?", "\\?", "[", "\\[", "]", "\\]").Replace(entry)
+	return fmt.Sprintf(LinuxUnzipPipeCmd, shellQuote(filename), shellQuote(entry))
+}
+
+// shellQuote quotes the value for sh, the names in the jar are not trusted
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
 }
 
 func GetEnvCmd(pid int) string {
@@ -34,7 +139,7 @@ func GetEnvCmd(pid int) string {
 }
 
 func GetJdkVersionCmd(javaCmd string) string {
-	return fmt.Sprintf(LinuxGetJdkVersionCmd, javaCmd)
+	return fmt.Sprintf(LinuxGetJdkVersionCmd, shellQuote(javaCmd))
 }
 
 func GetTotalMemoryCmd() string {
@@ -42,25 +147,21 @@ func GetTotalMemoryCmd() string {
 }
 
 func GetDefaultMaxHeap(javaCmd string) string {
-	return fmt.Sprintf(LinuxGetDefaultMaxHeapCmd, javaCmd)
-}
-
-func GetPortsCmd(pid int) string {
-	return fmt.Sprintf(LinuxGetPortsCmd, pid)
+	return fmt.Sprintf(LinuxGetDefaultMaxHeapCmd, shellQuote(javaCmd))
 }
 
-func GetOsName() string {
-	return fmt.Sprintf(LinuxGetOsName)
+func GetNetTcpCmd(pid int) string {
+	return fmt.Sprintf(LinuxGetNetTcpCmd, pid)
 }
 
-func GetOsVersion() string {
-	return fmt.Sprintf(LinuxGetOsVersion)
+func GetNetTcp6Cmd(pid int) string {
+	return fmt.Sprintf(LinuxGetNetTcp6Cmd, pid)
 }
 
-func GetCentOsName() string {
-	return fmt.Sprintf(CentOsGetName)
+func GetOsReleaseCmd() string {
+	return LinuxGetOsReleaseCmd
 }
 
-func GetCentOsVersion() string {
-	return fmt.Sprintf(CentOsGetVersion)
+func GetCentOsReleaseCmd() string {
+	return CentOsGetReleaseCmd
 }
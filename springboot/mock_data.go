@@ -2,13 +2,14 @@ package springboot
 
 import (
 	"fmt"
+	"strings"
 )
 
 var (
-	ExecutableProcess   = fmt.Sprintf(" %d 1000 java -javaagent:/path/to/applicationinsights.jar -XX:InitialRAMPercentage=60.0 -XX:MaxRAMPercentage=60.0 -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.password=testpassword1234 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=health,metrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234 -jar %s", ExecutableProcessId, ExecutableJarFile)
-	SpringBoot2xProcess = fmt.Sprintf("%d     0 /usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234 -jar %s", SpringBoot2xProcessId, SpringBoot2xJarFileLocation)
-	SpringBoot1xProcess = fmt.Sprintf("%d     0 /usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234 -jar %s", SpringBoot1xProcessId, SpringBoot1xJarFileLocation)
-	ErrorProcess        = fmt.Sprintf("%d     0 /usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234 -jar %s", ErrorProcessId, SpringBoot1xJarFileLocation)
+	ExecutableProcess   = ProcCmdline(ExecutableProcessId, append(strings.Fields("java -javaagent:/path/to/applicationinsights.jar -XX:InitialRAMPercentage=60.0 -XX:MaxRAMPercentage=60.0 -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.password=testpassword1234 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=health,metrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234"), JarOption, ExecutableJarFile)...)
+	SpringBoot2xProcess = ProcCmdline(SpringBoot2xProcessId, append(strings.Fields("/usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234"), JarOption, SpringBoot2xJarFileLocation)...)
+	SpringBoot1xProcess = ProcCmdline(SpringBoot1xProcessId, append(strings.Fields("/usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234"), JarOption, SpringBoot1xJarFileLocation)...)
+	ErrorProcess        = ProcCmdline(ErrorProcessId, append(strings.Fields("/usr/bin/qemu-x86_64 /usr/bin/java -javaagent:/path/to/applicationinsights.jar -Xmx128m -Xms128m -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=1099 -Dcom.sun.management.jmxremote.local.only=true -Dmanagement.endpoints.jmx.exposure.include=healthmetrics -Dcom.sun.management.jmxremote.authenticate=false -Dcom.sun.management.jmxremote.ssl=false -DtestOption=abc=def -Dspring.jmx.enabled=true -Dserver.tomcat.mbeanregistry.enabled=true -Dfile.encoding=UTF8 -Dspring.config.import=optional:configserver:/ -Dcom.sun.management.jmxremote.password=testpassword1234"), JarOption, SpringBoot1xJarFileLocation)...)
 
 	Manifest = `Manifest-Version: 1.0
 Created-By: Maven Jar Plugin 3.2.0
@@ -45,13 +46,19 @@ Spring-Boot-Layers-Index: BOOT-INF/layers.idx
 		"-jar",
 	}
 
-	TotalMemory = "  987654321\n"
+	TotalMemory = "MemTotal:       987654321 kB\nMemFree:         1234567 kB\n"
 
-	DefaultMaxHeapSize = "  987654321\n"
+	DefaultMaxHeapSize = "    size_t MaxHeapSize                              = 987654321                                 {product} {ergonomic}\n"
 
 	Checksum = "  987654321\n"
 
-	RuntimeJdkVersion = " 11.0.16_232\n"
+	RuntimeJdkVersion = "openjdk version \"11.0.16_232\" 2022-07-19\nOpenJDK Runtime Environment (build 11.0.16+8)\n"
+
+	JavaExe = "/usr/lib/jvm/java-11-openjdk-amd64/bin/java"
+
+	JdkReleaseFile = "IMPLEMENTOR=\"Eclipse Adoptium\"\nIMPLEMENTOR_VERSION=\"Temurin-11.0.16+8\"\nJAVA_VERSION=\"11.0.16\"\nJAVA_RUNTIME_VERSION=\"11.0.16+8\"\nOS_ARCH=\"x86_64\"\n"
+
+	ProcStatus = "Name:\tjava\nUmask:\t0022\nState:\tS (sleeping)\nUid:\t1000\t1000\t1000\t1000\nGid:\t1000\t1000\t1000\t1000\n"
 
 	SpringBoot1xAppName = "hellospring1x"
 	SpringBoot2xAppName = "hellospring2x"
@@ -76,7 +83,31 @@ Spring-Boot-Layers-Index: BOOT-INF/layers.idx
 	SpringBoot1xJarFileLocation = fmt.Sprintf("/home/azure/%s", SpringBoot1xJarFile)
 	ExecutableJarFileLocation   = fmt.Sprintf("/home/azure/%s", ExecutableJarFile)
 
-	Ports = "  22\n 8080\n38193\n44981"
+	FdLinks = fmt.Sprintf(`total 0
+lr-x------ 1 azure azure 64 Feb  5 10:04 0 -> /dev/null
+l-wx------ 1 azure azure 64 Feb  5 10:04 1 -> /home/azure/my logs/app.log
+lr-x------ 1 azure azure 64 Feb  5 10:04 4 -> %s
+lrwx------ 1 azure azure 64 Feb  5 10:04 5 -> socket:[32471]
+lrwx------ 1 azure azure 64 Feb  5 10:04 6 -> socket:[32472]
+lrwx------ 1 azure azure 64 Feb  5 10:04 7 -> socket:[32473]
+lrwx------ 1 azure azure 64 Feb  5 10:04 8 -> socket:[32480]
+`, ExecutableJarFileLocation)
+
+	// NetTcp listens 22 and 8080, and has a connection to 38193
+	NetTcp = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 32471 1 0000000000000000 100 0 0 10 0
+   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 32472 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:9531 01 00000000:00000000 00:00000000 00000000  1000        0 32480 1 0000000000000000 20 4 30 10 -1
+   3: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 11111 1 0000000000000000 100 0 0 10 0
+`
+
+	// NetTcp6 listens 44981 and 8080 again
+	NetTcp6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:AFB5 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 32473 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 32472 1 0000000000000000 100 0 0 10 0
+`
+
+	OsRelease = "NAME=\"Ubuntu\"\nVERSION=\"18.04.6 LTS (Bionic Beaver)\"\nID=ubuntu\nID_LIKE=debian\nVERSION_ID=\"18.04\"\n"
 
 	Host = "centos-8-openjdk11"
 
@@ -198,3 +229,8 @@ spring.datasource.hikari.jdbc-url=jdbc:mysql://localhost:3306/test?useUnicode=tr
 spring.datasource.password=testpassword1234
 `
 )
+
+// ProcCmdline gives /proc/<pid>/cmdline with the header, as in the output of the process scan
+func ProcCmdline(pid int, args ...string) string {
+	return fmt.Sprintf("==> /proc/%d/cmdline <==\n%s\000", pid, strings.Join(args, "\000"))
+}
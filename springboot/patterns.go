@@ -1,6 +1,7 @@
 package springboot
 
 import (
+	"fmt"
 	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
 	"regexp"
 )
@@ -37,7 +38,7 @@ func newPatterns() *patterns {
 	for _, path := range YamlCfg.Pattern.Logging.ConsoleOutput.Yamlpath {
 		p, err := yamlpath.NewPath(path)
 		if err != nil {
-			panic(err)
+			panic(fmt.Sprintf("invalid yamlpath %q in pattern.logging.console_output.yamlpath, %s", path, err.Error()))
 		}
 		ys = append(ys, p)
 	}
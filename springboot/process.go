@@ -6,7 +6,7 @@ import (
 	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"math"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -21,17 +21,24 @@ const (
 )
 
 type javaProcess struct {
-	pid          int
-	uid          int
-	options      []string
+	pid     int
+	uid     int
+	options []string
+	// optionSources are the options of the command line, the argfiles expanded
+	optionSources []JvmOption
+	// environments are all the environment variables of the process, the denylist not applied
 	environments []string
 	javaCmd      string
-	executor     ServerDiscovery
+	// exe is the java executable the process runs, resolved by /proc/<pid>/exe
+	exe      string
+	release  *JdkRelease
+	cwd      string
+	links    []string
+	executor ServerDiscovery
 }
 
 func (p *javaProcess) LocateJarFile() (string, error) {
 	var jarFileName string
-	var absolutePath string
 	for idx, option := range p.options {
 		if option == JarOption && idx+1 < len(p.options) {
 			jarFileName = p.options[idx+1]
@@ -41,48 +48,165 @@ func (p *javaProcess) LocateJarFile() (string, error) {
 	if len(jarFileName) == 0 {
 		return "", errors.New(fmt.Sprintf("jar file not found in process %d", p.pid))
 	}
-	if !filepath.IsAbs(jarFileName) {
+	if !path.IsAbs(jarFileName) {
 		// when jar file path is not absolute path, we shall locate the jar file path again
-		output, err := runWithSudo(p.executor.Server(), GetLocateJarCmd(p.pid, filepath.Base(jarFileName)))
+		return p.locateJarFile(jarFileName)
+	}
+
+	return jarFileName, nil
+}
+
+// locateJarFile finds the relative jar file in the files opened by the process, or in the working dir of the process
+func (p *javaProcess) locateJarFile(jarFileName string) (string, error) {
+	links, err := p.fdLinks()
+	if err != nil {
+		return "", err
+	}
+	for _, link := range links {
+		if path.IsAbs(link) && path.Base(link) == path.Base(jarFileName) {
+			return link, nil
+		}
+	}
+
+	cwd, err := p.workingDir()
+	if err != nil {
+		return "", err
+	}
+	if !path.IsAbs(cwd) {
+		return "", errors.New(fmt.Sprintf("cannot locate jar: %s", jarFileName))
+	}
+	return path.Join(cwd, jarFileName), nil
+}
+
+// workingDir gives the working dir of the process
+func (p *javaProcess) workingDir() (string, error) {
+	if len(p.cwd) == 0 {
+		output, err := runWithSudo(p.executor.Server(), GetCwdCmd(p.pid))
 		if err != nil {
 			return "", err
 		}
-		if len(output) == 0 {
-			return "", errors.New(fmt.Sprintf("cannot locate jar: %s", jarFileName))
+		for _, target := range parseFdLinks(output) {
+			p.cwd = target
 		}
-		absolutePath = output
-	} else {
-		absolutePath = jarFileName
 	}
+	return p.cwd, nil
+}
 
-	return CleanOutput(absolutePath), nil
+// fdLinks gives the targets of the files opened by the process
+func (p *javaProcess) fdLinks() ([]string, error) {
+	if p.links == nil {
+		output, err := runWithSudo(p.executor.Server(), GetFdLinksCmd(p.pid))
+		if err != nil {
+			return nil, err
+		}
+		// not nil, so the links are listed once even if none
+		p.links = append([]string{}, parseFdLinks(output)...)
+	}
+	return p.links, nil
 }
 
+// GetRuntimeJdkVersion gives the version of the release file of the JDK, or of java -version when the file is missing
 func (p *javaProcess) GetRuntimeJdkVersion() (string, error) {
-	buf, err := runWithSudo(p.executor.Server(), GetJdkVersionCmd(p.javaCmd))
+	if release, _ := p.GetJdkRelease(); release != nil && len(release.Version) > 0 {
+		return release.Version, nil
+	}
+	buf, err := runWithSudo(p.executor.Server(), GetJdkVersionCmd(p.executable()))
 	if err != nil {
 		return "", err
 	}
 
-	return CleanOutput(buf), nil
+	return parseJdkVersion(buf)
+}
+
+// GetJdkRelease reads the release file of JAVA_HOME of the java executable, nil if the executable is not in a JDK or a JRE
+func (p *javaProcess) GetJdkRelease() (*JdkRelease, error) {
+	if p.release != nil {
+		return p.release, nil
+	}
+	// nil when the release file is missing, e.g. a JRE of the distro, whose bin/java may be a link in /usr/bin
+	for _, home := range javaHomes(p.executable()) {
+		if output, err := runWithSudo(p.executor.Server(), GetJdkReleaseCmd(home)); err == nil {
+			p.release = parseJdkRelease(home, output)
+			return p.release, nil
+		}
+	}
+	return nil, nil
+}
+
+// executable gives the java executable of /proc/<pid>/exe, the java of the command line if the link cannot be read,
+// or /proc/<pid>/exe itself if the executable was deleted, e.g. the JDK upgraded under the running process,
+// which still runs the deleted one while its path is now another java or nothing, and has no java home
+func (p *javaProcess) executable() string {
+	if len(p.exe) == 0 {
+		p.exe = p.javaCmd
+		if output, err := runWithSudo(p.executor.Server(), GetExeCmd(p.pid)); err == nil {
+			for _, target := range parseFdLinks(output) {
+				if strings.HasSuffix(target, " (deleted)") {
+					p.exe = fmt.Sprintf("/proc/%d/exe", p.pid)
+				} else {
+					p.exe = target
+				}
+			}
+		}
+	}
+	return p.exe
 }
 
+// GetJvmOptions gives the options of the environment variables and of the command line, in the precedence order of the JVM
 func (p *javaProcess) GetJvmOptions() ([]string, error) {
-	var jvmOptions []string
-	var jarOpIdx = -1
-	for idx, option := range p.options {
-		if strings.EqualFold(option, JarOption) {
-			jarOpIdx = idx
-			continue
+	options, err := p.GetJvmOptionSources()
+	if err != nil {
+		return nil, err
+	}
+	return jvmOptionValues(options), nil
+}
+
+// GetJvmOptionSources gives the jvm options as GetJvmOptions, each with the source of it,
+// only the options of the command line when the environment of the process cannot be read
+func (p *javaProcess) GetJvmOptionSources() ([]JvmOption, error) {
+	environments, _ := p.environ()
+	cmdline := p.optionSources
+	if cmdline == nil {
+		for _, option := range p.options {
+			cmdline = append(cmdline, JvmOption{Value: option, Source: JvmOptionSourceCmdline})
 		}
-		if jarOpIdx != -1 && idx == jarOpIdx+1 {
-			// this is jar file
-			continue
+	}
+	return mergeJvmOptions(environments, cmdline), nil
+}
+
+// expandArgFiles reads the @argfiles of the command line, the options are kept as they are if any argfile cannot be read,
+// the uid of the process must be read before
+func (p *javaProcess) expandArgFiles() error {
+	sources, err := expandArgFiles(p.options, func(file string) (string, error) {
+		if !path.IsAbs(file) {
+			cwd, err := p.workingDir()
+			if err != nil {
+				return "", err
+			}
+			file = path.Join(cwd, file)
 		}
-		jvmOptions = append(jvmOptions, option)
+		return p.readArgFile(file)
+	})
+	if err != nil {
+		return err
 	}
+	p.optionSources = sources
+	p.options = jvmOptionValues(sources)
+	return nil
+}
 
-	return jvmOptions, nil
+// readArgFile reads the argfile by the login user, or by the owner of the process when permission denied, never by root,
+// as any user can start a process whose command line names a file only root can read
+func (p *javaProcess) readArgFile(file string) (string, error) {
+	server := p.executor.Server()
+	output, err := server.RunCmd(GetReadArgFileCmd(file))
+	if errors.As(err, &PermissionDenied{}) {
+		output, err = server.RunPrivilegedCmd(GetReadArgFileAsOwnerCmd(p.uid, file))
+	}
+	if err != nil {
+		return "", err
+	}
+	return output, nil
 }
 
 var envSplitter bufio.SplitFunc = func(data []byte, atEOF bool) (advance int, token []byte, err error) {
@@ -105,43 +229,60 @@ var envSplitter bufio.SplitFunc = func(data []byte, atEOF bool) (advance int, to
 }
 
 func (p *javaProcess) GetEnvironments() ([]string, error) {
+	all, err := p.environ()
+	if err != nil {
+		return nil, err
+	}
+	var environments []string
+	for _, env := range all {
+		idx := strings.Index(env, "=")
+
+		if idx > 0 {
+			envName := env[:idx]
+			if !Contains(YamlCfg.Env.Denylist, envName) {
+				environments = append(environments, env)
+			}
+		} else {
+			environments = append(environments, env)
+		}
+	}
+	return environments, nil
+}
+
+// environ reads all the environment variables of the process once
+func (p *javaProcess) environ() ([]string, error) {
 	if p.environments == nil {
 		output, err := runWithSudo(p.executor.Server(), GetEnvCmd(p.pid))
 		if err != nil {
 			return nil, err
 		}
-		var environments []string
-		if len(output) == 0 {
-			return environments, nil
-		}
+		environments := []string{}
 		scanner := bufio.NewScanner(strings.NewReader(output))
+		scanner.Buffer(make([]byte, 0, 64*KiB), MiB)
 		scanner.Split(envSplitter)
-
 		for scanner.Scan() {
-			env := scanner.Text()
-			idx := strings.Index(env, "=")
-
-			if idx > 0 {
-				envName := env[:idx]
-				if !Contains(YamlCfg.Env.Denylist, envName) {
-					environments = append(environments, env)
-				}
-			} else {
-				environments = append(environments, env)
-			}
+			environments = append(environments, scanner.Text())
 		}
-		return environments, nil
-	} else {
-		return p.environments, nil
+		p.environments = environments
 	}
+	return p.environments, nil
 }
 
 func (p *javaProcess) GetJavaCmd() (string, error) {
 	return p.javaCmd, nil
 }
 
+// GetJvmMemory gives the max heap of the jvm options, the last one wins as the JVM does
 func (p *javaProcess) GetJvmMemory() (int64, error) {
-	for _, option := range p.options {
+	options, err := p.GetJvmOptions()
+	if err != nil {
+		return 0, err
+	}
+	// the last one goes first
+	for i, j := 0, len(options)-1; i < j; i, j = i+1, j-1 {
+		options[i], options[j] = options[j], options[i]
+	}
+	for _, option := range options {
 		if strings.HasPrefix(option, JvmOptionXmx) {
 			bs, err := units.RAMInBytes(option[len(JvmOptionXmx):])
 			if err != nil {
@@ -154,7 +295,7 @@ func (p *javaProcess) GetJvmMemory() (int64, error) {
 	// Do a second iteration here due to -Xmx has higher priority than -XX:MaxRamPercentage
 	// If both are set, -XX:MaxRamPercentage will be ignored
 	// So if nothing found in the first iteration, we try another round
-	for _, option := range p.options {
+	for _, option := range options {
 		if strings.HasPrefix(option, JvmOptionMaxRamPercentage) {
 			total, err := p.executor.GetTotalMemory()
 			if err != nil {
@@ -184,40 +325,40 @@ func (p *javaProcess) GetProcessId() int {
 	return p.pid
 }
 
+// GetPorts gives the ports listened by the sockets of the process
 func (p *javaProcess) GetPorts() ([]int, error) {
-	output, err := runWithSudo(p.executor.Server(), GetPortsCmd(p.pid))
+	links, err := p.fdLinks()
 	if err != nil {
 		return nil, err
 	}
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	var ports []int
-	for scanner.Scan() {
-		text := scanner.Text()
-		if len(strings.TrimSpace(text)) > 0 {
-			if port, e := strconv.Atoi(strings.TrimSpace(text)); e == nil {
-				ports = append(ports, port)
-			}
-		}
+	inodes := socketInodes(links)
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	output, err := runWithSudo(p.executor.Server(), GetNetTcpCmd(p.pid))
+	if err != nil {
+		return nil, err
+	}
+	ports := parseListenPorts(output, inodes)
+	// tcp6 is missing when ipv6 is disabled
+	if output, err = runWithSudo(p.executor.Server(), GetNetTcp6Cmd(p.pid)); err == nil {
+		ports = append(ports, parseListenPorts(output, inodes)...)
 	}
 
-	return ports, nil
+	return uniquePorts(ports), nil
 }
 
 func (p *javaProcess) getDefaultMaxHeapSize() (int64, error) {
-	output, err := runWithSudo(p.executor.Server(), GetDefaultMaxHeap(p.javaCmd))
+	output, err := runWithSudo(p.executor.Server(), GetDefaultMaxHeap(p.executable()))
 	if err != nil {
 		return 0, err
 	}
-	if len(output) == 0 {
+	if len(strings.TrimSpace(output)) == 0 {
 		return 0, errors.New("failed to get default MaxHeapSize, output is empty")
 	}
 
-	size, err := strconv.ParseFloat(CleanOutput(output), 64)
-	if err != nil {
-		return 0, errors.Wrap(err, fmt.Sprintf("failed to parse default MaxHeapSize, output: %s", output))
-	}
-
-	return int64(size), nil
+	return parseMaxHeapSize(output)
 }
 
 func (p *javaProcess) String() string {
@@ -230,11 +371,12 @@ func (p *javaProcess) GetUid() int {
 	return p.uid
 }
 
+// runWithSudo runs the command by the login user, and by the escalation of the credential when permission denied
 func runWithSudo(server ServerConnector, cmd string) (string, error) {
 	output, err := server.RunCmd(cmd)
 	if err != nil {
 		if errors.As(err, &PermissionDenied{}) {
-			output, err = server.RunCmd(sudo(cmd))
+			output, err = server.RunPrivilegedCmd(cmd)
 		}
 		if err != nil {
 			return "", err
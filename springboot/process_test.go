@@ -28,6 +28,7 @@ var _ = Describe("Linux java process", func() {
 			uid:      uid,
 			options:  append(TestJvmOptions, jar),
 			javaCmd:  JavaCmd,
+			exe:      JavaExe,
 			executor: executor,
 		}
 		m.EXPECT().FQDN().Return("mock_server").AnyTimes()
@@ -55,9 +56,15 @@ var _ = Describe("Linux java process", func() {
 				process.options = append(TestJvmOptions, "../../../../"+jar)
 			})
 			It("should return the output folder after sanitized", func() {
-				m.EXPECT().RunCmd(gomock.Eq(fmt.Sprintf(LinuxLocateJarCmd, pid, jar))).Return(fmt.Sprintf(" /home/azureuser/%s\n", jar), nil)
+				m.EXPECT().RunCmd(gomock.Eq(GetFdLinksCmd(pid))).Return(fmt.Sprintf("total 0\nlr-x------ 1 azureuser azureuser 64 2月  5 10:04 4 -> /home/azureuser/%s\n", jar), nil)
 				Expect(process.LocateJarFile()).Should(Equal(fmt.Sprintf("/home/azureuser/%s", jar)))
 			})
+
+			It("should locate the jar file in the working dir when it is not opened", func() {
+				m.EXPECT().RunCmd(gomock.Eq(GetFdLinksCmd(pid))).Return("total 0\n", nil)
+				m.EXPECT().RunCmd(gomock.Eq(GetCwdCmd(pid))).Return("lrwxrwxrwx 1 azure azure 0 Feb  5 10:04 /proc/1/cwd -> /home/azure user/app/target/a/b\n", nil)
+				Expect(process.LocateJarFile()).Should(Equal("/home/azure user/" + jar))
+			})
 		})
 
 		When("when jvm options has a absolute jar file path", func() {
@@ -79,21 +86,67 @@ var _ = Describe("Linux java process", func() {
 	})
 
 	Context("Get runtime jdk version", func() {
+		When("got release file", func() {
+			It("should return the version of the release file without running java", func() {
+				m.EXPECT().RunCmd(GetJdkReleaseCmd("/usr/lib/jvm/java-11-openjdk-amd64")).Return(JdkReleaseFile, nil)
+				m.EXPECT().RunCmd(GetJdkVersionCmd(JavaExe)).MaxTimes(0)
+				Expect(process.GetRuntimeJdkVersion()).Should(Equal("11.0.16"))
+			})
+		})
 		When("got success output", func() {
 			It("should return sanitized version", func() {
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxGetJdkVersionCmd, "java")).Return(RuntimeJdkVersion, nil)
+				m.EXPECT().RunCmd(GetJdkReleaseCmd("/usr/lib/jvm/java-11-openjdk-amd64")).Return("", fmt.Errorf("No such file or directory"))
+				m.EXPECT().RunCmd(GetJdkVersionCmd(JavaExe)).Return(RuntimeJdkVersion, nil)
 				Expect(process.GetRuntimeJdkVersion()).Should(MatchVersion("11"))
 			})
 		})
 		When("got error", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxGetJdkVersionCmd, "java")).Return("", fmt.Errorf("test error message"))
+				m.EXPECT().RunCmd(GetJdkReleaseCmd("/usr/lib/jvm/java-11-openjdk-amd64")).Return("", fmt.Errorf("No such file or directory"))
+				m.EXPECT().RunCmd(GetJdkVersionCmd(JavaExe)).Return("", fmt.Errorf("test error message"))
 				Expect(process.GetRuntimeJdkVersion()).Error().Should(HaveOccurred())
 			})
 		})
 	})
 
+	Context("Get jdk release", func() {
+		BeforeEach(func() {
+			process.exe = ""
+		})
+
+		It("should read the release file of the java home of the executable", func() {
+			m.EXPECT().RunCmd(GetExeCmd(pid)).Return("lrwxrwxrwx 1 app app 0 Feb  5 10:04 /proc/1/exe -> /opt/jdk8u352/jre/bin/java\n", nil)
+			m.EXPECT().RunCmd(GetJdkReleaseCmd("/opt/jdk8u352")).Return("JAVA_VERSION=\"1.8.0_352\"\nIMPLEMENTOR=\"Azul Systems, Inc.\"\nOS_ARCH=\"amd64\"\n", nil)
+			Expect(process.GetJdkRelease()).Should(Equal(&JdkRelease{JavaHome: "/opt/jdk8u352", Version: "1.8.0_352", Vendor: JdkVendorZulu, Implementor: "Azul Systems, Inc.", Arch: "amd64"}))
+		})
+
+		It("should give no release when the release file is missing", func() {
+			m.EXPECT().RunCmd(GetExeCmd(pid)).Return("lrwxrwxrwx 1 app app 0 Feb  5 10:04 /proc/1/exe -> /usr/lib/jvm/java-8-openjdk/jre/bin/java\n", nil)
+			m.EXPECT().RunCmd(GetJdkReleaseCmd("/usr/lib/jvm/java-8-openjdk")).Return("", fmt.Errorf("No such file or directory"))
+			m.EXPECT().RunCmd(GetJdkReleaseCmd("/usr/lib/jvm/java-8-openjdk/jre")).Return("", fmt.Errorf("No such file or directory"))
+			Expect(process.GetJdkRelease()).Should(BeNil())
+		})
+
+		It("should run the deleted exe by its proc link", func() {
+			m.EXPECT().RunCmd(GetExeCmd(pid)).Return("lrwxrwxrwx 1 app app 0 Feb  5 10:04 /proc/1/exe -> /opt/jdk8u352/jre/bin/java (deleted)\n", nil)
+			m.EXPECT().RunCmd(GetJdkVersionCmd("/proc/1/exe")).Return(RuntimeJdkVersion, nil)
+			Expect(process.GetJdkRelease()).Should(BeNil())
+			Expect(process.executable()).Should(Equal("/proc/1/exe"))
+			Expect(process.GetRuntimeJdkVersion()).Should(MatchVersion("11"))
+		})
+
+		It("should fall back to the java of the command line when the exe cannot be read", func() {
+			m.EXPECT().RunCmd(GetExeCmd(pid)).Return("", fmt.Errorf("test error message"))
+			Expect(process.GetJdkRelease()).Should(BeNil())
+			Expect(process.executable()).Should(Equal(JavaCmd))
+		})
+	})
+
 	Context("Get jvm options", func() {
+		BeforeEach(func() {
+			process.environments = []string{}
+		})
+
 		When("jvm options is empty", func() {
 			BeforeEach(func() {
 				process.options = nil
@@ -117,6 +170,62 @@ var _ = Describe("Linux java process", func() {
 				))
 			})
 		})
+
+		When("jvm options are set by the environment variables", func() {
+			It("should merge them in the precedence order of the JVM", func() {
+				process.environments = []string{
+					"_JAVA_OPTIONS=-Xmx2g",
+					"JDK_JAVA_OPTIONS=-Dapp.title='my app' -Xmx1g",
+					"JAVA_TOOL_OPTIONS=-javaagent:/opt/agent.jar",
+				}
+				process.options = []string{"-Xmx512m", JarOption, jar, "--server.port=8080"}
+				Expect(process.GetJvmOptionSources()).Should(Equal([]JvmOption{
+					{Value: "-javaagent:/opt/agent.jar", Source: JvmOptionSourceJavaToolOptions},
+					{Value: "-Dapp.title=my app", Source: JvmOptionSourceJdkJavaOptions},
+					{Value: "-Xmx1g", Source: JvmOptionSourceJdkJavaOptions},
+					{Value: "-Xmx512m", Source: JvmOptionSourceCmdline},
+					{Value: "--server.port=8080", Source: JvmOptionSourceCmdline},
+					{Value: "-Xmx2g", Source: JvmOptionSourceJavaOptions},
+				}))
+				Expect(process.GetJvmMemory()).Should(Equal(int64(2 * 1024 * MiB)))
+			})
+		})
+
+		When("the environment cannot be read", func() {
+			It("should return the options of the command line", func() {
+				process.environments = nil
+				m.EXPECT().RunCmd(GetEnvCmd(pid)).Return("", fmt.Errorf("test error message"))
+				Expect(process.GetJvmOptions()).Should(ContainElement("-DtestOption=abc=def"))
+			})
+		})
+
+		When("jvm options are in the argfiles", func() {
+			It("should expand the argfiles relative to the working dir", func() {
+				process.options = []string{"@jvm.options", "@@literal", JarOption, jar, "@app.args"}
+				m.EXPECT().RunCmd(GetCwdCmd(pid)).Return("lrwxrwxrwx 1 app app 0 Feb  5 10:04 /proc/1/cwd -> /opt/my app\n", nil)
+				m.EXPECT().RunCmd(GetReadArgFileCmd("/opt/my app/jvm.options")).Return("# heap\n-Xmx256m \"-Dapp.home=/opt/my app\"\n", nil)
+				Expect(process.expandArgFiles()).Should(Succeed())
+				Expect(process.optionSources).Should(Equal([]JvmOption{
+					{Value: "-Xmx256m", Source: JvmOptionSourceArgFile, ArgFile: "jvm.options"},
+					{Value: "-Dapp.home=/opt/my app", Source: JvmOptionSourceArgFile, ArgFile: "jvm.options"},
+					{Value: "@literal", Source: JvmOptionSourceCmdline},
+					{Value: JarOption, Source: JvmOptionSourceCmdline},
+					{Value: jar, Source: JvmOptionSourceCmdline},
+					{Value: "@app.args", Source: JvmOptionSourceCmdline},
+				}))
+				m.EXPECT().RunCmd(GetFdLinksCmd(pid)).Return("total 0\n", nil)
+				Expect(process.LocateJarFile()).Should(Equal("/opt/my app/" + jar))
+			})
+
+			It("should read the argfile denied to the login user as the owner of the process, never as root", func() {
+				process.options = []string{"@/etc/shadow", JarOption, jar}
+				m.EXPECT().RunCmd(GetReadArgFileCmd("/etc/shadow")).Return("", PermissionDenied{error: fmt.Errorf("permission denied")})
+				m.EXPECT().RunPrivilegedCmd(GetReadArgFileAsOwnerCmd(uid, "/etc/shadow")).Return("", fmt.Errorf("head: cannot open '/etc/shadow' for reading: Permission denied"))
+				Expect(process.expandArgFiles()).ShouldNot(Succeed())
+				Expect(process.options).Should(Equal([]string{"@/etc/shadow", JarOption, jar}))
+				Expect(process.optionSources).Should(BeNil())
+			})
+		})
 	})
 
 	Context("Get environments", func() {
@@ -142,6 +251,10 @@ var _ = Describe("Linux java process", func() {
 	})
 
 	Context("Get jvm heap memory size", func() {
+		BeforeEach(func() {
+			process.environments = []string{}
+		})
+
 		When("Set -XX:MaxRAMPercentage only in jvm options", func() {
 			var percentage float64
 			BeforeEach(func() {
@@ -154,7 +267,7 @@ var _ = Describe("Linux java process", func() {
 
 			It("should return the jvm heap memory size based on the percentage of total memory", func() {
 				totalMemoryInKb := int64(1000000)
-				m.EXPECT().RunCmd(LinuxGetTotalMemoryCmd).Return(fmt.Sprintf("%v\n", totalMemoryInKb), nil)
+				m.EXPECT().RunCmd(LinuxGetTotalMemoryCmd).Return(fmt.Sprintf("MemTotal: %v kB\n", totalMemoryInKb), nil)
 				Expect(process.GetJvmMemory()).Should(Equal(int64(math.Round(float64(totalMemoryInKb*KiB)*percentage) / 100)))
 			})
 		})
@@ -199,7 +312,7 @@ var _ = Describe("Linux java process", func() {
 			})
 
 			It("should get default max jvm heap memory from vm", func() {
-				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaCmd)).Return(fmt.Sprintf("%v\n", size), nil)
+				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaExe)).Return(fmt.Sprintf("   size_t MaxHeapSize = %v {product} {ergonomic}\n", size), nil)
 				m.EXPECT().RunCmd(LinuxGetTotalMemoryCmd).MaxTimes(0)
 				Expect(process.GetJvmMemory()).Should(Equal(size)) // keep 2 digits precision
 			})
@@ -209,19 +322,29 @@ var _ = Describe("Linux java process", func() {
 	Context("Get ports", func() {
 		When("got success output after run cmd", func() {
 			It("should return ports in list", func() {
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxGetPortsCmd, pid)).Return(Ports, nil)
-				Expect(process.GetPorts()).Should(ContainElements(22, 38193, 8080))
+				m.EXPECT().RunCmd(GetFdLinksCmd(pid)).Return(FdLinks, nil)
+				m.EXPECT().RunCmd(GetNetTcpCmd(pid)).Return(NetTcp, nil)
+				m.EXPECT().RunCmd(GetNetTcp6Cmd(pid)).Return(NetTcp6, nil)
+				Expect(process.GetPorts()).Should(Equal([]int{22, 8080, 44981}))
+			})
+		})
+		When("ipv6 is disabled", func() {
+			It("should return the ports of ipv4", func() {
+				m.EXPECT().RunCmd(GetFdLinksCmd(pid)).Return(FdLinks, nil)
+				m.EXPECT().RunCmd(GetNetTcpCmd(pid)).Return(NetTcp, nil)
+				m.EXPECT().RunCmd(GetNetTcp6Cmd(pid)).Return("", fmt.Errorf("cat: /proc/%d/net/tcp6: No such file or directory", pid))
+				Expect(process.GetPorts()).Should(Equal([]int{22, 8080}))
 			})
 		})
 		When("got empty output after run cmd", func() {
 			It("should return empty list", func() {
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxGetPortsCmd, pid)).Return(fmt.Sprintf(""), nil)
+				m.EXPECT().RunCmd(GetFdLinksCmd(pid)).Return(fmt.Sprintf(""), nil)
 				Expect(process.GetPorts()).Should(BeEmpty())
 			})
 		})
 		When("got error while run cmd", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxGetPortsCmd, pid)).Return("", fmt.Errorf("test error message"))
+				m.EXPECT().RunCmd(GetFdLinksCmd(pid)).Return("", fmt.Errorf("test error message"))
 				Expect(process.GetPorts()).Error().ShouldNot(BeNil())
 			})
 		})
@@ -230,25 +353,25 @@ var _ = Describe("Linux java process", func() {
 	Context("Get default max heap size ", func() {
 		When("got success output", func() {
 			It("should return memory size in kb", func() {
-				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaCmd)).Return(DefaultMaxHeapSize, nil)
+				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaExe)).Return(DefaultMaxHeapSize, nil)
 				Expect(process.getDefaultMaxHeapSize()).Should(Equal(int64(987654321)))
 			})
 		})
 		When("got empty output", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaCmd)).Return("  \n", nil)
+				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaExe)).Return("  \n", nil)
 				Expect(process.getDefaultMaxHeapSize()).Error().Should(HaveOccurred())
 			})
 		})
 		When("got invalid output", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaCmd)).Return("  abcdefg\n", nil)
+				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaExe)).Return("   size_t MaxHeapSize = abcdefg {product} {ergonomic}\n", nil)
 				Expect(process.getDefaultMaxHeapSize()).Error().Should(HaveOccurred())
 			})
 		})
 		When("got error", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaCmd)).Return("", fmt.Errorf("test error message"))
+				m.EXPECT().RunCmd(GetDefaultMaxHeap(JavaExe)).Return("", fmt.Errorf("test error message"))
 				Expect(process.getDefaultMaxHeapSize()).Error().Should(HaveOccurred())
 			})
 		})
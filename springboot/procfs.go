@@ -0,0 +1,214 @@
+package springboot
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// procFileHeader is the header head prints before each file when reading many files
+var procFileHeader = regexp.MustCompile(`(?m)^==> /proc/(\d+)/(\w+) <==\n`)
+
+// procFile is a file of /proc/<pid> in the output of the process scan
+type procFile struct {
+	pid     int
+	name    string
+	content string
+}
+
+// parseProcFiles splits the output of head on many /proc/<pid> files, by the header of each file
+func parseProcFiles(output string) ([]procFile, error) {
+	output = strings.TrimLeft(output, "\n")
+	if len(strings.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+	headers := procFileHeader.FindAllStringSubmatchIndex(output, -1)
+	if len(headers) == 0 || headers[0][0] != 0 {
+		return nil, errors.New("unexpected output of process scan, the file header is missing")
+	}
+
+	var files []procFile
+	for i, header := range headers {
+		end := len(output)
+		if i+1 < len(headers) {
+			// head separates the files by an empty line
+			end = headers[i+1][0]
+			if end > header[1] && output[end-1] == '\n' {
+				end--
+			}
+		}
+		pid, err := strconv.Atoi(output[header[2]:header[3]])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse pid from process")
+		}
+		files = append(files, procFile{pid: pid, name: output[header[4]:header[5]], content: output[header[1]:end]})
+	}
+	return files, nil
+}
+
+// parseCmdline splits /proc/<pid>/cmdline by NUL, it is empty for the kernel threads
+func parseCmdline(content string) []string {
+	content = strings.TrimSuffix(content, "\000")
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(content, "\000")
+}
+
+// parseStatusUid gives the effective uid of /proc/<pid>/status, the line is Uid: real effective saved fs
+func parseStatusUid(status string) (int, error) {
+	scanner := bufio.NewScanner(strings.NewReader(status))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && fields[0] == "Uid:" {
+			uid, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return 0, errors.Wrap(err, "failed to parse uid from process status")
+			}
+			return uid, nil
+		}
+	}
+	return 0, errors.New("uid not found in process status")
+}
+
+// parseFdLinks gives the targets of the links listed by ls -l, e.g. /proc/<pid>/fd, whatever the locale formats the date
+func parseFdLinks(output string) []string {
+	var targets []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if _, target, found := strings.Cut(scanner.Text(), " -> "); found {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// socketInodes gives the inodes of the socket:[inode] links
+func socketInodes(targets []string) map[string]bool {
+	inodes := make(map[string]bool)
+	for _, target := range targets {
+		if strings.HasPrefix(target, "socket:[") && strings.HasSuffix(target, "]") {
+			inodes[target[len("socket:["):len(target)-1]] = true
+		}
+	}
+	return inodes
+}
+
+// parseListenPorts gives the ports listened by the sockets of the inodes, in /proc/<pid>/net/tcp or tcp6,
+// the columns are sl local_address rem_address st ... inode, the port is in hex and the state of listen is 0A
+func parseListenPorts(content string, inodes map[string]bool) []int {
+	var ports []int
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != "0A" || !inodes[fields[9]] {
+			continue
+		}
+		idx := strings.LastIndex(fields[1], ":")
+		if idx < 0 {
+			continue
+		}
+		if port, err := strconv.ParseInt(fields[1][idx+1:], 16, 32); err == nil {
+			ports = append(ports, int(port))
+		}
+	}
+	return ports
+}
+
+// uniquePorts sorts the ports and removes the duplicated, a port listened on both ipv4 and ipv6 is once
+func uniquePorts(ports []int) []int {
+	sort.Ints(ports)
+	var unique []int
+	for i, port := range ports {
+		if i == 0 || port != ports[i-1] {
+			unique = append(unique, port)
+		}
+	}
+	return unique
+}
+
+// parseReleaseFile parses the KEY=value lines of /etc/os-release or of the release file of a JDK, the value may be quoted
+func parseReleaseFile(content string) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, "\"") {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, "'\"")
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// parseCentOsRelease gives the name and the version of /etc/centos-release, e.g. CentOS Linux release 7.9.2009 (Core)
+func parseCentOsRelease(content string) (string, string) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	for i, field := range fields {
+		if field == "release" && i+1 < len(fields) {
+			return fields[0], fields[i+1]
+		}
+	}
+	return fields[0], ""
+}
+
+// parseMemTotal gives MemTotal of /proc/meminfo in KiB
+func parseMemTotal(meminfo string) (int64, error) {
+	scanner := bufio.NewScanner(strings.NewReader(meminfo))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			size, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, errors.Wrap(err, fmt.Sprintf("unable to parse total memory, output is %s", scanner.Text()))
+			}
+			return size, nil
+		}
+	}
+	return 0, errors.New("failed to get total memory, MemTotal not found")
+}
+
+// parseJdkVersion gives the version in the first line of java -version, e.g. openjdk version "11.0.16" 2022-07-19
+func parseJdkVersion(output string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, " version \""); idx >= 0 {
+			version, _, _ := strings.Cut(line[idx+len(" version \""):], "\"")
+			return version, nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("failed to get jdk version, output: %s", CleanOutput(output)))
+}
+
+// parseMaxHeapSize gives MaxHeapSize in -XX:+PrintFlagsFinal, e.g. size_t MaxHeapSize = 4164943872 {product} {ergonomic}
+func parseMaxHeapSize(output string) (int64, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && fields[1] == "MaxHeapSize" {
+			size, err := strconv.ParseFloat(fields[3], 64)
+			if err != nil {
+				return 0, errors.Wrap(err, fmt.Sprintf("failed to parse default MaxHeapSize, output: %s", scanner.Text()))
+			}
+			return int64(size), nil
+		}
+	}
+	return 0, errors.New("failed to get default MaxHeapSize, not found in the output")
+}
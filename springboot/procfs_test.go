@@ -0,0 +1,65 @@
+package springboot
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse /proc", func() {
+	It("should split the files of the process scan by the headers", func() {
+		output := "==> /proc/1/cmdline <==\n/sbin/init\000splash\000\n" +
+			"==> /proc/2/cmdline <==\n\n" +
+			"==> /proc/42/cmdline <==\n/opt/my jdk/bin/java\000-Dapp.name=a b\000-jar\000/opt/my app/app.jar\000"
+		files, err := parseProcFiles(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).Should(HaveLen(3))
+		Expect(parseCmdline(files[0].content)).Should(Equal([]string{"/sbin/init", "splash"}))
+		Expect(parseCmdline(files[1].content)).Should(BeEmpty())
+		Expect(files[2].pid).Should(Equal(42))
+		Expect(files[2].name).Should(Equal("cmdline"))
+		Expect(parseCmdline(files[2].content)).Should(Equal([]string{"/opt/my jdk/bin/java", "-Dapp.name=a b", "-jar", "/opt/my app/app.jar"}))
+
+		_, err = parseProcFiles("  PID   UID CMD\n")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should read the effective uid of the status", func() {
+		Expect(parseStatusUid("Name:\tjava\nUid:\t0\t1001\t1001\t1001\n")).Should(Equal(1001))
+		Expect(parseStatusUid("Name:\tjava\n")).Error().Should(HaveOccurred())
+	})
+
+	It("should read the fd links whatever the locale", func() {
+		links := parseFdLinks("total 0\n" +
+			"lr-x------ 1 app app 64 févr. 5 10:04 3 -> /opt/my app/app.jar\n" +
+			"lrwx------ 1 app app 64 2023-02-05 10:04 4 -> socket:[1234]\n" +
+			"lrwx------    1 app      app             64 Feb  5 10:04 5 -> anon_inode:[eventpoll]\n")
+		Expect(links).Should(Equal([]string{"/opt/my app/app.jar", "socket:[1234]", "anon_inode:[eventpoll]"}))
+		Expect(socketInodes(links)).Should(Equal(map[string]bool{"1234": true}))
+	})
+
+	It("should read the ports listened by the sockets of the process", func() {
+		inodes := socketInodes(parseFdLinks(FdLinks))
+		Expect(parseListenPorts(NetTcp, inodes)).Should(Equal([]int{22, 8080}))
+		Expect(parseListenPorts(NetTcp6, inodes)).Should(Equal([]int{44981, 8080}))
+		Expect(uniquePorts([]int{8080, 22, 8080, 44981})).Should(Equal([]int{22, 8080, 44981}))
+	})
+
+	It("should read the os release", func() {
+		release := parseReleaseFile("# alpine\nNAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.18.4\nPRETTY_NAME='Alpine Linux v3.18'\n")
+		Expect(release["NAME"]).Should(Equal("Alpine Linux"))
+		Expect(release["ID"]).Should(Equal("alpine"))
+		Expect(release["VERSION_ID"]).Should(Equal("3.18.4"))
+		Expect(release["PRETTY_NAME"]).Should(Equal("Alpine Linux v3.18"))
+
+		name, version := parseCentOsRelease("CentOS Linux release 7.9.2009 (Core)\n")
+		Expect(name).Should(Equal("CentOS"))
+		Expect(version).Should(Equal("7.9.2009"))
+	})
+
+	It("should read the memory, the jdk version and the default max heap", func() {
+		Expect(parseMemTotal(TotalMemory)).Should(Equal(int64(987654321)))
+		Expect(parseJdkVersion(RuntimeJdkVersion)).Should(Equal("11.0.16_232"))
+		Expect(parseJdkVersion("Picked up JAVA_TOOL_OPTIONS: -Xmx1g\njava version \"1.8.0_292\"\n")).Should(Equal("1.8.0_292"))
+		Expect(parseMaxHeapSize("    uintx MaxHeapSize                              := 2061500416                          {product}\n")).Should(Equal(int64(2061500416)))
+	})
+})
@@ -0,0 +1,93 @@
+package springboot
+
+import (
+	"fmt"
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "Info"
+	SeverityWarning  Severity = "Warning"
+	SeverityCritical Severity = "Critical"
+
+	largeJvmMemory = 8 * units.GiB
+)
+
+// Finding is a migration readiness concern of an app
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// AssessReadiness checks the app against the known migration concerns, e.g. the end of life versions and the file based logging
+func AssessReadiness(app *SpringBootApp) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, format string, args ...any) {
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+	runtime := runtimeOf(app)
+
+	if !SpringBootAppTypes.Contains(app.AppType) {
+		add("app-type", SeverityInfo, "%s is not a Spring Boot app, the readiness of the framework is not assessed", app.AppType)
+	} else if len(app.SpringBootVersion) == 0 {
+		add("spring-boot-version", SeverityWarning, "Spring Boot version cannot be detected")
+	} else if LessThan(app.SpringBootVersion, "2") {
+		add("spring-boot-version", SeverityCritical, "Spring Boot %s is end of life, upgrade to 2.7 or later", app.SpringBootVersion)
+	} else if LessThan(app.SpringBootVersion, "3") {
+		add("spring-boot-version", SeverityWarning, "Spring Boot %s is out of open source support, consider upgrading to 3.x", app.SpringBootVersion)
+	}
+
+	if len(runtime.RuntimeJdkVersion) == 0 {
+		add("runtime-jdk", SeverityWarning, "Runtime JDK version cannot be detected")
+	} else if IsValidJdkVersion(runtime.RuntimeJdkVersion) && LessThan(runtime.RuntimeJdkVersion, "11") {
+		add("runtime-jdk", SeverityWarning, "Running on JDK %s, consider JDK 17 or later", runtime.RuntimeJdkVersion)
+	}
+	if len(app.BuildJdkVersion) > 0 && IsValidJdkVersion(app.BuildJdkVersion) && LessThan(app.BuildJdkVersion, "1.8") {
+		add("build-jdk", SeverityWarning, "Built with JDK %s, which is not supported by the modern runtimes", app.BuildJdkVersion)
+	}
+
+	for _, name := range sortedKeys(app.LoggingConfigurations) {
+		if !logsToConsole(name, app.LoggingConfigurations[name]) {
+			add("file-logging", SeverityWarning, "%s writes the logs to files only, add a console appender so the logs can be collected", name)
+		}
+	}
+	if len(app.Certificates) > 0 {
+		add("certificates", SeverityInfo, "%d certificate files are bundled, make sure they are trusted on the target", len(app.Certificates))
+	}
+	if len(app.StaticContentLocations) > 0 {
+		add("static-content", SeverityInfo, "Static content is served from %s, consider moving it to a storage or CDN", strings.Join(app.StaticContentLocations, ", "))
+	}
+	if runtime.JvmMemory > largeJvmMemory {
+		add("jvm-memory", SeverityWarning, "JVM heap is %s, check the memory limit of the target", units.BytesSize(float64(runtime.JvmMemory)))
+	}
+	return findings
+}
+
+// logsToConsole checks the logging config against pattern.logging.console_output, the yamlpath is used for the yaml and json files
+func logsToConsole(filename string, content string) bool {
+	for _, p := range Patterns.ConsoleOutputRegexPatterns {
+		if p.MatchString(content) {
+			return true
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yml", ".yaml", ".json", ".jsn":
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+			return false
+		}
+		for _, path := range Patterns.ConsoleOutputYamlPatterns {
+			if found, err := path.Find(&node); err == nil && len(found) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package springboot
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Readiness assessment", func() {
+	var app *SpringBootApp
+
+	BeforeEach(func() {
+		app = &SpringBootApp{
+			AppName:           "hellospring",
+			AppType:           SpringBootFatJar,
+			SpringBootVersion: "3.1.0",
+			BuildJdkVersion:   "17",
+			Runtime:           &Runtime{RuntimeJdkVersion: "17.0.6", JvmMemory: 512 * MiB},
+		}
+	})
+
+	rules := func(findings []Finding) []string {
+		var names []string
+		for _, f := range findings {
+			names = append(names, f.Rule)
+		}
+		return names
+	}
+
+	When("the app is up to date", func() {
+		It("should have no finding", func() {
+			Expect(AssessReadiness(app)).Should(BeEmpty())
+		})
+	})
+
+	When("the versions are outdated", func() {
+		It("should report end of life spring boot as critical", func() {
+			app.SpringBootVersion = SpringBoot1xVersion
+			app.BuildJdkVersion = "1.7"
+			app.Runtime.RuntimeJdkVersion = "1.8.0_292"
+			findings := AssessReadiness(app)
+			Expect(rules(findings)).Should(ConsistOf("spring-boot-version", "runtime-jdk", "build-jdk"))
+			Expect(findings[0].Severity).Should(Equal(SeverityCritical))
+		})
+
+		It("should report spring boot 2 as warning", func() {
+			app.SpringBootVersion = SpringBoot2xVersion
+			findings := AssessReadiness(app)
+			Expect(findings).Should(HaveLen(1))
+			Expect(findings[0].Severity).Should(Equal(SeverityWarning))
+		})
+	})
+
+	When("the app is not a spring boot app", func() {
+		It("should not assess the spring boot version", func() {
+			app.AppType = ExecutableJar
+			app.SpringBootVersion = ""
+			Expect(rules(AssessReadiness(app))).Should(ConsistOf("app-type"))
+		})
+	})
+
+	When("the logs are written to files", func() {
+		It("should report the logging config without console appender", func() {
+			app.LoggingConfigurations = map[string]string{
+				"log4j.properties":  "log4j.appender.file=org.apache.log4j.RollingFileAppender",
+				"log4j2.properties": "appender.console.type = Console",
+				"log4j2.yml":        "Configuration:\n  appenders:\n    console:\n      name: STDOUT\n",
+			}
+			findings := AssessReadiness(app)
+			Expect(rules(findings)).Should(ConsistOf("file-logging"))
+			Expect(findings[0].Message).Should(ContainSubstring("log4j.properties"))
+		})
+	})
+
+	When("the app bundles certificates, static content and a large heap", func() {
+		It("should report them", func() {
+			app.Certificates = []string{"server.p12"}
+			app.StaticContentLocations = []string{"/static"}
+			app.Runtime.JvmMemory = 16 * 1024 * MiB
+			Expect(rules(AssessReadiness(app))).Should(ConsistOf("certificates", "static-content", "jvm-memory"))
+		})
+	})
+
+	When("runtime is missing", func() {
+		It("should not panic", func() {
+			app.Runtime = nil
+			Expect(rules(AssessReadiness(app))).Should(ConsistOf("runtime-jdk"))
+		})
+	})
+})
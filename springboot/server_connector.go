@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"io"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -25,20 +28,34 @@ func DefaultServerConnectorFactory(opts ...SshOption) ServerConnectorFactory {
 
 func (f *linuxServerFactory) Create(ctx context.Context, host string, port int) ServerConnector {
 	s := &linuxServer{
-		server: host,
-		port:   port,
-		ctx:    ctx,
+		server:      host,
+		port:        port,
+		ctx:         ctx,
+		maxChannels: DefaultMaxChannels,
 	}
 
 	for _, opt := range f.opts {
 		opt(s)
 	}
+	if s.jumpHostResolver != nil {
+		s.jumpHosts = s.jumpHostResolver(host, port)
+	}
+	// one of the channels is kept for the sftp session, a single command session at least
+	sessions := s.maxChannels - 1
+	if sessions < 1 {
+		sessions = 1
+	}
+	s.sessions = make(chan struct{}, sessions)
 	return s
 }
 
+// DefaultMaxChannels stays under MaxSessions of OpenSSH, which is 10 by default
+const DefaultMaxChannels = 8
+
 type linuxServer struct {
 	client   *ssh.Client
 	username string
+	password string
 	cb       ssh.HostKeyCallback
 	keyAlgos []string
 	timeout  time.Duration
@@ -46,16 +63,45 @@ type linuxServer struct {
 	port     int
 	ctx      context.Context
 	mux      sync.Mutex
+	// sftpClient is created on the first read and shared by the reads until the connection is closed
+	sftpClient *sftp.Client
+	// sessions limits the command sessions open at the same time, nil means unlimited
+	sessions    chan struct{}
+	maxChannels int
+	keepAlive   time.Duration
+	stop        chan struct{}
+	// jumps are the connections to the jump hosts the client is tunneled through
+	jumpHostResolver JumpHostResolver
+	jumpHosts        []JumpHost
+	jumps            []*ssh.Client
+	proxy            *Proxy
+	escalation       *Escalation
+	allowlist        *CommandAllowlist
+	audit            *Audit
 }
 
 func (s *linuxServer) RunCmd(cmd string) (string, error) {
-	if s.client == nil {
-		return "", ConnectionError{error: fmt.Errorf("server %s is not connected", s.server), message: "ssh client is nil"}
+	if err := s.allow(cmd); err != nil {
+		return "", err
+	}
+	var output string
+	err := s.retry(func(client *ssh.Client) error {
+		var err error
+		output, err = s.runCmd(client, cmd)
+		return err
+	})
+	return output, err
+}
+
+func (s *linuxServer) runCmd(client *ssh.Client, cmd string) (string, error) {
+	if s.sessions != nil {
+		s.sessions <- struct{}{}
+		defer func() { <-s.sessions }()
 	}
 	var session *ssh.Session
 	var err error
 
-	session, err = s.client.NewSession()
+	session, err = client.NewSession()
 	if err != nil {
 		return "", ConnectionError{error: err, message: fmt.Sprintf("failed to create new session, host: %s", s.server)}
 	}
@@ -64,8 +110,14 @@ func (s *linuxServer) RunCmd(cmd string) (string, error) {
 	var e bytes.Buffer
 	session.Stdout = &b
 	session.Stderr = &e
+	start := time.Now()
 	err = session.Run(cmd)
-	output := b.String()
+	s.audit.record(AuditRecord{Time: start, Host: s.server, User: s.username, Command: cmd, ExitStatus: exitStatus(err), DurationMs: time.Since(start).Milliseconds(), OutputBytes: b.Len(), Error: errorMessage(err)})
+	return s.cmdResult(cmd, b.String(), &e, err)
+}
+
+// cmdResult tells the permission denied and the failed command apart from the output
+func (s *linuxServer) cmdResult(cmd string, output string, e *bytes.Buffer, err error) (string, error) {
 	azureLogger := GetAzureLogger(s.ctx)
 	azureLogger.Debug("Running cmd on server", "cmd", cmd, "host", s.server)
 	if strings.Contains(e.String(), "Permission denied") {
@@ -74,33 +126,59 @@ func (s *linuxServer) RunCmd(cmd string) (string, error) {
 		return "", err
 	}
 	if err != nil {
-		if exitError, ok := err.(*ssh.ExitError); ok {
-			if exitError.ExitStatus() == 1 && strings.Contains(cmd, "grep") {
-				// for a grep command, exit code = 1 means no lines were returned
-				// https://linuxcommand.org/lc3_man_pages/grep1.html
-				return "", nil
-			}
-		}
 		azureLogger.Warning(err, "Running cmd on server failed", "cmd", cmd, "host", s.server, "output", e.String())
-		return "", toSshError(err, &e)
+		return "", toSshError(err, e)
 	}
 
 	return output, nil
 }
 
+// Close tears down the sftp session and the connection, and stops the keepalive
 func (s *linuxServer) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
 	if s.client == nil {
 		return nil
 	}
-	return s.client.Close()
+	if s.sftpClient != nil {
+		_ = s.sftpClient.Close()
+		s.sftpClient = nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	closeClients(s.jumps)
+	s.jumps = nil
+	return err
 }
 
+// Read opens the file over sftp, or reads it by commands when sftp is disabled or the file is only readable by sudo
 func (s *linuxServer) Read(location string) (io.ReaderAt, os.FileInfo, error) {
-	_, _, err := s.client.SendRequest("keepalive", false, nil)
-	if err != nil {
-		return nil, nil, err
+	var r io.ReaderAt
+	var info os.FileInfo
+	err := s.retry(func(client *ssh.Client) error {
+		var err error
+		r, info, err = s.readBySftp(client, location)
+		return err
+	})
+	if err == nil {
+		return r, info, nil
 	}
-	client, err := sftp.NewClient(s.client)
+
+	azureLogger := GetAzureLogger(s.ctx)
+	azureLogger.Info("cannot read over sftp, read by commands instead", "location", location, "host", s.server, "err", err.Error())
+	r, info, execErr := newExecReader(s, location)
+	if execErr != nil {
+		return nil, nil, errors.Wrap(execErr, fmt.Sprintf("read by commands failed after %s", err.Error()))
+	}
+	return r, info, nil
+}
+
+func (s *linuxServer) readBySftp(client *ssh.Client, location string) (io.ReaderAt, os.FileInfo, error) {
+	sftpClient, err := s.sftp(client)
 	if err != nil {
 		if isPermissionDenied(err) {
 			return nil, nil, PermissionDenied{error: err, message: fmt.Sprintf("create sftp client permission denied, server: %s, location: %s", s.server, location)}
@@ -109,7 +187,7 @@ func (s *linuxServer) Read(location string) (io.ReaderAt, os.FileInfo, error) {
 	}
 
 	// Read the source file
-	srcFile, err := client.OpenFile(location, os.O_RDONLY)
+	srcFile, err := sftpClient.OpenFile(location, os.O_RDONLY)
 	if err != nil {
 		if isPermissionDenied(err) {
 			return nil, nil, PermissionDenied{error: err, message: fmt.Sprintf("read jar file over sftp permission deinied, server: %s, location: %s", s.server, location)}
@@ -119,13 +197,32 @@ func (s *linuxServer) Read(location string) (io.ReaderAt, os.FileInfo, error) {
 
 	stat, err := srcFile.Stat()
 	if err != nil {
+		_ = srcFile.Close()
 		if isPermissionDenied(err) {
 			return nil, nil, PermissionDenied{error: err, message: fmt.Sprintf("stat jar file permission denied: server: %s, location: %s", s.server, location)}
 		}
 		return nil, nil, ConnectionError{error: err, message: fmt.Sprintf("stat jar file failed: server: %s, location: %s", s.server, location)}
 	}
 
-	return srcFile, stat, nil
+	return &sftpFile{s: s, location: location, client: client, file: srcFile}, stat, nil
+}
+
+// sftp creates the sftp session of the connection on the first read
+func (s *linuxServer) sftp(client *ssh.Client) (*sftp.Client, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.client != client {
+		return nil, ConnectionError{error: fmt.Errorf("server %s is reconnected", s.server), message: "ssh client is replaced"}
+	}
+	if s.sftpClient != nil {
+		return s.sftpClient, nil
+	}
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, err
+	}
+	s.sftpClient = sftpClient
+	return sftpClient, nil
 }
 
 func (s *linuxServer) FQDN() string {
@@ -133,6 +230,41 @@ func (s *linuxServer) FQDN() string {
 }
 
 func (s *linuxServer) Connect(username, password string) error {
+	client, jumps, err := s.dial(username, password)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.client != nil {
+		// the credentials may be tried in parallel, only the last connection is kept
+		_ = s.client.Close()
+		closeClients(s.jumps)
+	}
+	s.client = client
+	s.jumps = jumps
+	s.username = username
+	s.password = password
+	if s.keepAlive > 0 && s.stop == nil {
+		s.stop = make(chan struct{})
+		go s.keepConnectionAlive(s.stop)
+	}
+
+	return nil
+}
+
+// dial connects to the server, through the jump hosts if any
+func (s *linuxServer) dial(username, password string) (*ssh.Client, []*ssh.Client, error) {
+	// connect ot ssh server
+	connectString := net.JoinHostPort(s.server, strconv.Itoa(s.port))
+	if len(s.jumpHosts) > 0 {
+		return s.dialThrough(connectString, username, password)
+	}
+	client, err := s.hop(nil, connectString, s.clientConfig(username, password, s.cb))
+	return client, nil, err
+}
+
+func (s *linuxServer) clientConfig(username, password string, cb ssh.HostKeyCallback) *ssh.ClientConfig {
 	azureLogger := GetAzureLogger(s.ctx)
 	var auth []ssh.AuthMethod
 	auth = append(auth, ssh.Password(password))
@@ -146,7 +278,7 @@ func (s *linuxServer) Connect(username, password string) error {
 	cfg := &ssh.ClientConfig{
 		User:            username,
 		Auth:            auth,
-		HostKeyCallback: s.cb,
+		HostKeyCallback: cb,
 		BannerCallback: func(message string) error {
 			azureLogger.Info(message)
 			return nil
@@ -159,19 +291,7 @@ func (s *linuxServer) Connect(username, password string) error {
 		cfg.KeyExchanges = append(cfg.KeyExchanges, s.keyAlgos...)
 	}
 	cfg.MACs = append(cfg.MACs, "ssh-dss")
-
-	// connect ot ssh server
-	connectString := fmt.Sprintf("%s:%d", s.server, s.port)
-	client, err := ssh.Dial("tcp", connectString, cfg)
-	if err != nil {
-		return err
-	}
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	s.client = client
-	s.username = username
-
-	return nil
+	return cfg
 }
 
 func (s *linuxServer) Username() string {
@@ -203,6 +323,21 @@ func WithConnectionTimeout(timeout time.Duration) SshOption {
 	}
 }
 
+// WithKeepAlive probes the connection in the interval, a dropped connection is reconnected
+func WithKeepAlive(interval time.Duration) SshOption {
+	return func(s *linuxServer) {
+		s.keepAlive = interval
+	}
+}
+
+// WithMaxChannels limits the channels open at the same time, one of them is the sftp session,
+// the commands are run one at a time when it is 2 or less
+func WithMaxChannels(max int) SshOption {
+	return func(s *linuxServer) {
+		s.maxChannels = max
+	}
+}
+
 func WithKeyAlgorithms(algos []string) SshOption {
 	return func(s *linuxServer) {
 		s.keyAlgos = algos
@@ -212,3 +347,134 @@ func WithKeyAlgorithms(algos []string) SshOption {
 func (s *linuxServer) String() string {
 	return s.server
 }
+
+func (s *linuxServer) currentClient() *ssh.Client {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.client
+}
+
+// retry runs the operation again on a new connection, when it failed because the connection dropped
+func (s *linuxServer) retry(op func(client *ssh.Client) error) error {
+	client := s.currentClient()
+	if client == nil {
+		return ConnectionError{error: fmt.Errorf("server %s is not connected", s.server), message: "ssh client is nil"}
+	}
+	err := op(client)
+	if !s.lost(client, err) {
+		return err
+	}
+
+	azureLogger := GetAzureLogger(s.ctx)
+	azureLogger.Warning(err, "connection dropped, reconnecting", "host", s.server)
+	if reconnectErr := s.reconnect(client); reconnectErr != nil {
+		return ConnectionError{error: reconnectErr, message: fmt.Sprintf("reconnect to %s failed after %s", s.server, err.Error())}
+	}
+	return op(s.currentClient())
+}
+
+// lost tells whether the operation failed because of the connection, the command errors are not
+func (s *linuxServer) lost(client *ssh.Client, err error) bool {
+	if err == nil || IsPermissionDenied(err) || IsEscalationError(err) {
+		return false
+	}
+	var exitError *ssh.ExitError
+	if errors.As(err, &exitError) {
+		return false
+	}
+	return !alive(client)
+}
+
+func alive(client *ssh.Client) bool {
+	// any reply, even a failure for the unknown request, proves the connection is alive
+	_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
+}
+
+// reconnect replaces the broken client, unless it was replaced by another operation already
+func (s *linuxServer) reconnect(broken *ssh.Client) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.client != broken {
+		if s.client == nil {
+			return fmt.Errorf("server %s is closed", s.server)
+		}
+		return nil
+	}
+	if s.sftpClient != nil {
+		_ = s.sftpClient.Close()
+		s.sftpClient = nil
+	}
+	_ = broken.Close()
+	closeClients(s.jumps)
+	s.jumps = nil
+
+	client, jumps, err := s.dial(s.username, s.password)
+	if err != nil {
+		s.client = nil
+		return err
+	}
+	s.client = client
+	s.jumps = jumps
+	return nil
+}
+
+func (s *linuxServer) keepConnectionAlive(stop chan struct{}) {
+	azureLogger := GetAzureLogger(s.ctx)
+	ticker := time.NewTicker(s.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			client := s.currentClient()
+			if client == nil || alive(client) {
+				continue
+			}
+			if err := s.reconnect(client); err != nil {
+				azureLogger.Warning(err, "keepalive failed to reconnect", "host", s.server)
+			}
+		}
+	}
+}
+
+// sftpFile reopens the file on the new connection, when the connection dropped in the middle of reading
+type sftpFile struct {
+	s        *linuxServer
+	location string
+	mux      sync.Mutex
+	client   *ssh.Client
+	file     *sftp.File
+}
+
+func (f *sftpFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	n, err := f.file.ReadAt(p, off)
+	if err == nil || err == io.EOF || !f.s.lost(f.client, err) {
+		return n, err
+	}
+
+	if err = f.s.reconnect(f.client); err != nil {
+		return n, err
+	}
+	client := f.s.currentClient()
+	sftpClient, err := f.s.sftp(client)
+	if err != nil {
+		return n, err
+	}
+	file, err := sftpClient.Open(f.location)
+	if err != nil {
+		return n, err
+	}
+	_ = f.file.Close()
+	f.client, f.file = client, file
+	return f.file.ReadAt(p, off)
+}
+
+func (f *sftpFile) Close() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.file.Close()
+}
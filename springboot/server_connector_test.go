@@ -0,0 +1,444 @@
+package springboot
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testSshServer runs the commands by echo and serves the local files over sftp
+type testSshServer struct {
+	listener   net.Listener
+	config     *ssh.ServerConfig
+	mux        sync.Mutex
+	conns      []net.Conn
+	dials      int32
+	sftps      int32
+	tunnels    int32
+	running    int32
+	maxRunning int32
+	cmdDelay   time.Duration
+	// exec runs the command instead of the echo, and gives the exit status
+	exec func(channel ssh.Channel, cmd string, pty bool) uint32
+}
+
+func newTestSshServer() *testSshServer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).ShouldNot(HaveOccurred())
+	signer, err := ssh.NewSignerFromKey(key)
+	Expect(err).ShouldNot(HaveOccurred())
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "mockuser" && string(password) == "mockpass" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("bad password")
+		},
+	}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ShouldNot(HaveOccurred())
+
+	server := &testSshServer{listener: listener, config: config}
+	go server.serve()
+	return server
+}
+
+func (t *testSshServer) port() int {
+	return t.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (t *testSshServer) serve() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		t.mux.Lock()
+		t.conns = append(t.conns, conn)
+		t.mux.Unlock()
+		go t.handle(conn)
+	}
+}
+
+func (t *testSshServer) handle(conn net.Conn) {
+	_, channels, requests, err := ssh.NewServerConn(conn, t.config)
+	if err != nil {
+		return
+	}
+	atomic.AddInt32(&t.dials, 1)
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() == "direct-tcpip" {
+			go t.tunnel(newChannel)
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go t.session(channel, channelRequests)
+	}
+}
+
+// tunnel forwards the channel to the address in the request, as the jump host does
+func (t *testSshServer) tunnel(newChannel ssh.NewChannel) {
+	var target struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+	if err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	atomic.AddInt32(&t.tunnels, 1)
+	go ssh.DiscardRequests(requests)
+	go func() {
+		_, _ = io.Copy(conn, channel)
+		_ = conn.Close()
+	}()
+	_, _ = io.Copy(channel, conn)
+	_ = channel.Close()
+}
+
+func (t *testSshServer) session(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	var pty bool
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			pty = true
+			_ = req.Reply(true, nil)
+		case "exec":
+			_ = req.Reply(true, nil)
+			if t.exec != nil {
+				status := make([]byte, 4)
+				binary.BigEndian.PutUint32(status, t.exec(channel, string(req.Payload[4:]), pty))
+				_, _ = channel.SendRequest("exit-status", false, status)
+				return
+			}
+			running := atomic.AddInt32(&t.running, 1)
+			for {
+				max := atomic.LoadInt32(&t.maxRunning)
+				if running <= max || atomic.CompareAndSwapInt32(&t.maxRunning, max, running) {
+					break
+				}
+			}
+			time.Sleep(t.cmdDelay)
+			atomic.AddInt32(&t.running, -1)
+			_, _ = channel.Write(req.Payload[4:])
+			status := make([]byte, 4)
+			binary.BigEndian.PutUint32(status, 0)
+			_, _ = channel.SendRequest("exit-status", false, status)
+			return
+		case "subsystem":
+			_ = req.Reply(true, nil)
+			atomic.AddInt32(&t.sftps, 1)
+			server, err := sftp.NewServer(channel, sftp.ReadOnly())
+			if err != nil {
+				return
+			}
+			_ = server.Serve()
+			return
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// drop closes the connections from the server side, as if the network dropped
+func (t *testSshServer) drop() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, conn := range t.conns {
+		_ = conn.Close()
+	}
+	t.conns = nil
+}
+
+func (t *testSshServer) close() {
+	_ = t.listener.Close()
+	t.drop()
+}
+
+var _ = Describe("Linux server connector", func() {
+	var (
+		server *testSshServer
+		jar    string
+	)
+
+	BeforeEach(func() {
+		server = newTestSshServer()
+		jar = filepath.Join(GinkgoT().TempDir(), "app.jar")
+		Expect(os.WriteFile(jar, []byte("not really a jar"), 0600)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	connect := func(opts ...SshOption) ServerConnector {
+		opts = append([]SshOption{WithHostKeyCallback(ssh.InsecureIgnoreHostKey()), WithConnectionTimeout(5 * time.Second)}, opts...)
+		connector := DefaultServerConnectorFactory(opts...).Create(context.Background(), "127.0.0.1", server.port())
+		Expect(connector.Connect("mockuser", "mockpass")).Should(Succeed())
+		return connector
+	}
+
+	read := func(connector ServerConnector) string {
+		r, info, err := connector.Read(jar)
+		Expect(err).ShouldNot(HaveOccurred())
+		b, err := io.ReadAll(io.NewSectionReader(r, 0, info.Size()))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(r.(io.Closer).Close()).Should(Succeed())
+		return string(b)
+	}
+
+	It("should reuse the sftp session and close it with the connection", func() {
+		connector := connect()
+		Expect(read(connector)).Should(Equal("not really a jar"))
+		Expect(read(connector)).Should(Equal("not really a jar"))
+		Expect(atomic.LoadInt32(&server.sftps)).Should(Equal(int32(1)))
+
+		Expect(connector.Close()).Should(Succeed())
+		_, err := connector.RunCmd("echo")
+		Expect(IsConnectionError(err)).Should(BeTrue())
+	})
+
+	It("should limit the command sessions", func() {
+		server.cmdDelay = 20 * time.Millisecond
+		connector := connect(WithMaxChannels(3))
+		defer connector.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := connector.RunCmd("echo")
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+		Expect(atomic.LoadInt32(&server.maxRunning)).Should(Equal(int32(2)))
+	})
+
+	It("should run the commands one at a time by a single channel", func() {
+		server.cmdDelay = 20 * time.Millisecond
+		connector := connect(WithMaxChannels(1))
+		defer connector.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := connector.RunCmd("echo")
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+		Expect(atomic.LoadInt32(&server.maxRunning)).Should(Equal(int32(1)))
+	})
+
+	It("should reconnect and retry when the connection dropped", func() {
+		connector := connect()
+		defer connector.Close()
+		Expect(read(connector)).Should(Equal("not really a jar"))
+
+		server.drop()
+		output, err := connector.RunCmd("echo hello")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output).Should(Equal("echo hello"))
+		Expect(read(connector)).Should(Equal("not really a jar"))
+		Expect(atomic.LoadInt32(&server.dials)).Should(Equal(int32(2)))
+		Expect(atomic.LoadInt32(&server.sftps)).Should(Equal(int32(2)))
+	})
+
+	It("should reconnect by keepalive", func() {
+		connector := connect(WithKeepAlive(10 * time.Millisecond))
+		defer connector.Close()
+
+		server.drop()
+		Eventually(func() int32 {
+			return atomic.LoadInt32(&server.dials)
+		}).WithTimeout(2 * time.Second).Should(Equal(int32(2)))
+	})
+
+	It("should reject the commands not on the allowlist and audit the commands", func() {
+		allowlist, err := NewCommandAllowlist("echo %s")
+		Expect(err).ShouldNot(HaveOccurred())
+		var transcript bytes.Buffer
+		audit := NewAudit(&transcript)
+		connector := connect(WithCommandAllowlist(allowlist), WithAudit(audit))
+		defer connector.Close()
+
+		output, err := connector.RunCmd("echo hello")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(output).Should(Equal("echo hello"))
+		_, err = connector.RunCmd("echo hello; id")
+		Expect(IsCommandRejected(err)).Should(BeTrue())
+		_, err = connector.RunPrivilegedCmd("id")
+		Expect(IsCommandRejected(err)).Should(BeTrue())
+		Expect(audit.Err()).ShouldNot(HaveOccurred())
+
+		var records []AuditRecord
+		decoder := json.NewDecoder(&transcript)
+		for decoder.More() {
+			var record AuditRecord
+			Expect(decoder.Decode(&record)).Should(Succeed())
+			records = append(records, record)
+		}
+		Expect(records).Should(HaveLen(3))
+		Expect(records[0].Command).Should(Equal("echo hello"))
+		Expect(records[0].Host).Should(Equal("127.0.0.1"))
+		Expect(records[0].User).Should(Equal("mockuser"))
+		Expect(records[0].ExitStatus).Should(Equal(0))
+		Expect(records[0].OutputBytes).Should(Equal(len("echo hello")))
+		Expect(records[0].Time).ShouldNot(BeZero())
+		Expect(records[1].Command).Should(Equal("echo hello; id"))
+		Expect(records[1].ExitStatus).Should(Equal(-1))
+		Expect(records[1].Error).Should(ContainSubstring("allowlist"))
+		Expect(records[2].Command).Should(Equal("id"))
+	})
+
+	Context("jump hosts", func() {
+		var bastion, gateway *testSshServer
+
+		BeforeEach(func() {
+			bastion = newTestSshServer()
+			gateway = newTestSshServer()
+		})
+
+		AfterEach(func() {
+			bastion.close()
+			gateway.close()
+		})
+
+		It("should tunnel the connection through the chain", func() {
+			connector := connect(WithJumpHosts(
+				JumpHost{Server: "127.0.0.1", Port: bastion.port()},
+				JumpHost{Server: "127.0.0.1", Port: gateway.port(), Username: "mockuser", Password: "mockpass"},
+			))
+			defer connector.Close()
+
+			output, err := connector.RunCmd("echo hello")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(output).Should(Equal("echo hello"))
+			Expect(read(connector)).Should(Equal("not really a jar"))
+			Expect(atomic.LoadInt32(&bastion.tunnels)).Should(Equal(int32(1)))
+			Expect(atomic.LoadInt32(&gateway.tunnels)).Should(Equal(int32(1)))
+			Expect(atomic.LoadInt32(&server.dials)).Should(Equal(int32(1)))
+		})
+
+		It("should resolve the jump hosts by the server", func() {
+			var resolved []string
+			connector := connect(WithJumpHostResolver(func(host string, port int) []JumpHost {
+				resolved = append(resolved, fmt.Sprintf("%s:%d", host, port))
+				return []JumpHost{{Server: "127.0.0.1", Port: bastion.port()}}
+			}))
+			defer connector.Close()
+
+			Expect(resolved).Should(Equal([]string{fmt.Sprintf("127.0.0.1:%d", server.port())}))
+			Expect(atomic.LoadInt32(&bastion.tunnels)).Should(Equal(int32(1)))
+		})
+
+		It("should verify the host key of the jump host by its own callback", func() {
+			connector := DefaultServerConnectorFactory(
+				WithHostKeyCallback(ssh.InsecureIgnoreHostKey()),
+				WithJumpHosts(JumpHost{Server: "127.0.0.1", Port: bastion.port(), HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+					return fmt.Errorf("unknown host key")
+				}}),
+			).Create(context.Background(), "127.0.0.1", server.port())
+			err := connector.Connect("mockuser", "mockpass")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("unknown host key"))
+			Expect(atomic.LoadInt32(&server.dials)).Should(Equal(int32(0)))
+		})
+
+		It("should offer the private key of the jump host by public key only, never as a password", func() {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).ShouldNot(HaveOccurred())
+			signer, err := ssh.NewSignerFromKey(key)
+			Expect(err).ShouldNot(HaveOccurred())
+			var passwords int32
+			bastion.config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+				atomic.AddInt32(&passwords, 1)
+				return nil, fmt.Errorf("bad password")
+			}
+			bastion.config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+				if bytes.Equal(key.Marshal(), signer.PublicKey().Marshal()) {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("unknown key")
+			}
+
+			connector := connect(WithJumpHosts(JumpHost{Server: "127.0.0.1", Port: bastion.port(), Signer: signer}))
+			defer connector.Close()
+
+			Expect(atomic.LoadInt32(&bastion.tunnels)).Should(Equal(int32(1)))
+			Expect(atomic.LoadInt32(&passwords)).Should(BeZero())
+		})
+
+		It("should reconnect through the chain when the jump host dropped", func() {
+			connector := connect(WithJumpHosts(JumpHost{Server: "127.0.0.1", Port: bastion.port()}))
+			defer connector.Close()
+
+			bastion.drop()
+			output, err := connector.RunCmd("echo hello")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(output).Should(Equal("echo hello"))
+			Expect(atomic.LoadInt32(&bastion.dials)).Should(Equal(int32(2)))
+		})
+	})
+})
+
+var _ = Describe("ProxyJump", func() {
+	It("should parse the chain of jump hosts", func() {
+		jumpHosts, err := ParseProxyJump("admin@bastion:2222, gateway,ops@[fd00::1]:22,fd00::2")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(jumpHosts).Should(Equal([]JumpHost{
+			{Server: "bastion", Port: 2222, Username: "admin"},
+			{Server: "gateway"},
+			{Server: "fd00::1", Port: 22, Username: "ops"},
+			{Server: "fd00::2"},
+		}))
+		Expect(jumpHosts[1].address()).Should(Equal("gateway:22"))
+	})
+
+	It("should be failed with an invalid port", func() {
+		_, err := ParseProxyJump("bastion:ssh")
+		Expect(err).Should(HaveOccurred())
+		_, err = ParseProxyJump("admin@:22")
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -1,17 +1,11 @@
 package springboot
 
 import (
-	"archive/zip"
-	"bufio"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/ssh"
-	"io"
-	"strconv"
 	"strings"
+	"time"
 )
 
 type AuthType int32
@@ -26,19 +20,40 @@ type linuxServerDiscovery struct {
 	server             ServerConnector
 	ctx                context.Context
 	cfg                YamlConfig
+	jarCache           JarCache
+	jarReadStrategy    JarReadStrategy
+	bandwidthLimit     int64
+	limiter            *rateLimiter
+	unzipAvailable     *bool
 }
 
+// DiscoveryOption customizes the server discovery, passed through by the executor to every server
+type DiscoveryOption func(l *linuxServerDiscovery)
+
 func NewLinuxServerDiscovery(
 	ctx context.Context,
 	serverConnector ServerConnector,
 	credentialProvider CredentialProvider,
-	cfg YamlConfig) ServerDiscovery {
-	return &linuxServerDiscovery{
+	cfg YamlConfig,
+	opts ...DiscoveryOption) ServerDiscovery {
+	l := &linuxServerDiscovery{
 		ctx:                ctx,
 		cfg:                cfg,
 		server:             serverConnector,
 		credentialProvider: credentialProvider,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.limiter = newRateLimiter(l.bandwidthLimit, l.bandwidthLimit)
+	return l
+}
+
+// WithJarCache skips reading the jar whose checksum is in the cache, and caches the jar read
+func WithJarCache(cache JarCache) DiscoveryOption {
+	return func(l *linuxServerDiscovery) {
+		l.jarCache = cache
+	}
 }
 
 func (l *linuxServerDiscovery) Server() ServerConnector {
@@ -53,67 +68,60 @@ func (l *linuxServerDiscovery) Prepare() (*Credential, error) {
 	return l.connect(creds...)
 }
 
+// ProcessScan finds the java processes running a jar by the command lines in /proc
 func (l *linuxServerDiscovery) ProcessScan() ([]JavaProcess, error) {
-
-	var output string
-	var err error
-	output, err = runWithSudo(l.Server(), GetProcessScanCmd())
+	azureLogger := GetAzureLogger(l.ctx)
+	output, err := runWithSudo(l.Server(), GetProcessScanCmd())
 	if err != nil {
-		if exitError, ok := err.(*ssh.ExitError); ok {
-			if exitError.ExitStatus() == 1 {
-				// when ps command return empty processes, linux will return the exit status 1
-				// we just ignore this kind of error
-			}
-		} else {
-			return nil, err
-		}
-
+		return nil, err
 	}
-	var processes []JavaProcess
-
-	if len(output) == 0 {
-		return processes, nil
+	files, err := parseProcFiles(output)
+	if err != nil {
+		return nil, err
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(strings.TrimSpace(line)) == 0 {
+	var processes []JavaProcess
+	for _, file := range files {
+		if file.name != "cmdline" {
 			continue
 		}
-		var process JavaProcess
-		process, err = func(line string) (JavaProcess, error) {
-			splits := strings.Fields(strings.TrimSpace(line))
-			var pid int
-			var uid int
-			pid, err = strconv.Atoi(splits[0])
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to parse pid from process")
-			}
-			uid, err = strconv.Atoi(splits[1])
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to parse uid from process")
-			}
-			start := 2
-			for _, split := range splits[start:] {
-				if strings.HasSuffix(split, JavaCmd) {
-					break
-				}
-				start++
-			}
-			if start >= len(splits) {
-				return nil, errors.New("cannot locate java command in scanned process options")
-			}
-			return &javaProcess{
-				pid:      pid,
-				uid:      uid,
-				javaCmd:  splits[start],
-				options:  splits[start+1:],
-				executor: l,
-			}, nil
-		}(line)
+		args := parseCmdline(file.content)
+		start := 0
+		for start < len(args) && !strings.HasSuffix(args[start], JavaCmd) {
+			start++
+		}
+		if start >= len(args) {
+			continue
+		}
+		process := &javaProcess{
+			pid:      file.pid,
+			javaCmd:  args[start],
+			options:  args[start+1:],
+			executor: l,
+		}
+		argFiles := hasArgFile(process.options)
+		if !argFiles && !Contains(process.options, JarOption) {
+			continue
+		}
+
+		// the uid is read first, the argfiles are read as the owner of the process
+		status, err := runWithSudo(l.Server(), GetProcStatusCmd(file.pid))
 		if err != nil {
-			return nil, err
+			// the process may have exited after the scan
+			azureLogger.Info("cannot read process status, skip it", "pid", file.pid, "err", err)
+			continue
+		}
+		if process.uid, err = parseStatusUid(status); err != nil {
+			azureLogger.Info("cannot parse process status, skip it", "pid", file.pid, "err", err)
+			continue
+		}
+		if argFiles {
+			if err = process.expandArgFiles(); err != nil {
+				azureLogger.Info("cannot read argfile of process, keep the options as they are", "pid", file.pid, "err", err)
+			}
+		}
+		if !Contains(process.options, JarOption) {
+			continue
 		}
 		processes = append(processes, process)
 	}
@@ -125,48 +133,52 @@ func (l *linuxServerDiscovery) GetTotalMemory() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	if len(output) == 0 {
+	if len(strings.TrimSpace(output)) == 0 {
 		return 0, errors.New("failed to get total memory, output is empty")
 	}
 
-	size, err := strconv.ParseFloat(CleanOutput(output), 64)
+	size, err := parseMemTotal(output)
 	if err != nil {
-		return 0, errors.Wrap(err, fmt.Sprintf("unable to parse total memory, output is %s", output))
+		return 0, err
 	}
 
-	return int64(size * KiB), nil
+	return size * KiB, nil
 }
 
 func (l *linuxServerDiscovery) getChecksum(absolutePath string) (string, error) {
 	azureLogger := GetAzureLogger(l.ctx)
 	output, err := runWithSudo(l.server, GetSha256Cmd(absolutePath))
-	if err != nil || len(output) == 0 {
+	fields := strings.Fields(output)
+	if err != nil || len(fields) == 0 {
 		azureLogger.Info("cannot get sha256 checksum", "absolutePath", absolutePath, "err", err)
 		return "", nil
 	}
-	return CleanOutput(output), nil
+	// the output is the checksum followed by the file name, sha256sum prefixes \ when the file name is escaped
+	return strings.TrimPrefix(fields[0], "\\"), nil
 }
 
 func (l *linuxServerDiscovery) GetOsName() (string, error) {
 	azureLogger := GetAzureLogger(l.ctx)
 	var tryOsRelease tryFunc[ServerConnector, string] = func(in ServerConnector) (string, bool) {
-		output, err := runWithSudo(in, GetOsName())
+		output, err := runWithSudo(in, GetOsReleaseCmd())
 		if err != nil {
 			azureLogger.Warning(err, "cannot get os name", "output", output)
 		}
-		return output, len(output) > 0
+		name := parseReleaseFile(output)["ID"]
+		return name, len(name) > 0
 	}
 	var tryCentOsRelease tryFunc[ServerConnector, string] = func(in ServerConnector) (string, bool) {
-		output, err := runWithSudo(in, GetCentOsName())
+		output, err := runWithSudo(in, GetCentOsReleaseCmd())
 		if err != nil {
 			azureLogger.Warning(err, "cannot get cent os name", "output", output)
 		}
-		return output, len(output) > 0
+		name, _ := parseCentOsRelease(output)
+		return name, len(name) > 0
 	}
 
 	output, found := tryFuncs[ServerConnector, string]{tryOsRelease, tryCentOsRelease}.try(l.server)
 	if found {
-		return CleanOutput(output), nil
+		return output, nil
 	}
 
 	return "", nil
@@ -175,87 +187,86 @@ func (l *linuxServerDiscovery) GetOsName() (string, error) {
 func (l *linuxServerDiscovery) GetOsVersion() (string, error) {
 	azureLogger := GetAzureLogger(l.ctx)
 	var tryOsRelease tryFunc[ServerConnector, string] = func(in ServerConnector) (string, bool) {
-		output, err := runWithSudo(in, GetOsVersion())
+		output, err := runWithSudo(in, GetOsReleaseCmd())
 		if err != nil {
 			azureLogger.Debug("cannot get os version", "err", err, "output", output)
 		}
-		return output, len(output) > 0
+		version := parseReleaseFile(output)["VERSION_ID"]
+		return version, len(version) > 0
 	}
 	var tryCentOsRelease tryFunc[ServerConnector, string] = func(in ServerConnector) (string, bool) {
-		output, err := runWithSudo(in, GetCentOsVersion())
+		output, err := runWithSudo(in, GetCentOsReleaseCmd())
 		if err != nil {
 			azureLogger.Debug("cannot get cent os version", "err", err, "output", output)
 		}
-		return output, len(output) > 0
+		_, version := parseCentOsRelease(output)
+		return version, len(version) > 0
 	}
 
 	output, found := tryFuncs[ServerConnector, string]{tryOsRelease, tryCentOsRelease}.try(l.server)
 	if found {
-		return CleanOutput(output), nil
+		return output, nil
 	}
 
 	return "", nil
 }
 
 func (l *linuxServerDiscovery) ReadJarFile(location string, walkers ...JarFileWalker) (JarFile, error) {
-	srcFile, fileInfo, err := l.server.Read(location)
-	if err != nil {
-		return nil, err
-	}
-	if closer, ok := srcFile.(io.Closer); ok {
-		defer closer.Close()
+	azureLogger := GetAzureLogger(l.ctx)
+	var checksum string
+	if l.jarCache != nil {
+		// the checksum goes first, so the jar in cache is never downloaded
+		checksum, _ = l.getChecksum(location)
+		if facts, ok := l.jarCache.Get(checksum); ok {
+			size, modTime, err := l.stat(location)
+			if err == nil {
+				azureLogger.Debug("jar file found in cache", "location", location, "checksum", checksum)
+				return newJarFileFromFacts(location, checksum, modTime, size, facts)
+			}
+			azureLogger.Info("cannot stat jar file, read it instead", "location", location, "err", err)
+		}
 	}
 
-	var reader *zip.Reader
-	reader, err = zip.NewReader(srcFile, fileInfo.Size())
-
+	var j *jarFile
+	var err error
+	if l.useUnzip() {
+		j, err = l.readJarByUnzip(location, checksum, walkers...)
+		if err != nil && l.jarReadStrategy == JarReadAuto {
+			azureLogger.Info("cannot read jar file by unzip, read it over sftp instead", "location", location, "err", err)
+			j, err = l.readJarBySftp(location, checksum, walkers...)
+		}
+	} else {
+		j, err = l.readJarBySftp(location, checksum, walkers...)
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("cannot read remote location: %s, %s", location, err.Error()))
+		return nil, err
 	}
 
-	checksum, _ := l.getChecksum(location)
-	if r, ok := srcFile.(io.Reader); ok && len(checksum) == 0 {
-		// this step will slow down the overall speed
-		h := sha256.New()
-		if _, err := io.Copy(h, r); err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("cannot get checksum for %s, %s", location, err.Error()))
+	if l.jarCache != nil {
+		if err = l.jarCache.Put(j.checksum, j.facts()); err != nil {
+			azureLogger.Warning(err, "cannot cache jar file", "location", location, "checksum", j.checksum)
 		}
-
-		checksum = hex.EncodeToString(h.Sum(nil))
 	}
+	return j, nil
+}
 
-	j := &jarFile{
-		checksum:                  checksum,
-		remoteLocation:            location,
-		applicationConfigurations: make(map[string]string),
-		loggingConfigs:            make(map[string]string),
-		manifests:                 make(map[string]string),
-		lastModifiedTime:          fileInfo.ModTime(),
-		size:                      fileInfo.Size(),
+// stat gets the size and the modified time of the file, without opening it over sftp
+func (l *linuxServerDiscovery) stat(location string) (int64, time.Time, error) {
+	output, err := runWithSudo(l.server, GetStatCmd(location))
+	if err != nil {
+		return 0, time.Time{}, err
 	}
-	for _, f := range reader.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-		for _, walker := range walkers {
-			err = walker(f.Name, f, j)
-			if err != nil {
-				return nil, err
-			}
-		}
+	info, err := parseStat(location, output)
+	if err != nil {
+		return 0, time.Time{}, err
 	}
-
-	return j, nil
+	return info.Size(), info.ModTime(), nil
 }
 
 func (l *linuxServerDiscovery) Finish() error {
 	return l.Server().Close()
 }
 
-func sudo(command string) string {
-	return "sudo " + command
-}
-
 func (l *linuxServerDiscovery) connect(creds ...*Credential) (*Credential, error) {
 	azureLogger := GetAzureLogger(l.ctx)
 	length := len(creds)
@@ -286,6 +297,7 @@ func (l *linuxServerDiscovery) connect(creds ...*Credential) (*Credential, error
 			}
 			continue
 		}
+		l.server.Escalate(result.cred.Escalation)
 		return result.cred, nil
 	}
 
@@ -1,10 +1,12 @@
 package springboot
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"fmt"
 	"github.com/onsi/gomega/types"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -51,9 +53,15 @@ var _ = Describe("Linux java process", func() {
 	Context("Process scan", func() {
 		When("got success output", func() {
 			It("should return process list", func() {
-				m.EXPECT().RunCmd(LinuxProcessScanCmd).Return(strings.Join([]string{ExecutableProcess, SpringBoot2xProcess}, "\n"), nil)
+				m.EXPECT().RunCmd(LinuxProcessScanCmd).Return(strings.Join([]string{ExecutableProcess, ProcCmdline(2, "/sbin/init"), ProcCmdline(3), SpringBoot2xProcess}, "\n"), nil)
+				m.EXPECT().RunCmd(GetProcStatusCmd(ExecutableProcessId)).Return(ProcStatus, nil)
+				m.EXPECT().RunCmd(GetProcStatusCmd(SpringBoot2xProcessId)).Return("", fmt.Errorf("cat: /proc/1/status: No such file or directory"))
+				m.EXPECT().RunCmd(GetEnvCmd(ExecutableProcessId)).Return(TestEnv, nil)
 				processes, _ := executor.ProcessScan()
-				Expect(processes).Should(HaveLen(2))
+				Expect(processes).Should(HaveLen(1))
+				Expect(processes[0].GetProcessId()).Should(Equal(ExecutableProcessId))
+				Expect(processes[0].GetUid()).Should(Equal(1000))
+				Expect(processes[0].GetJavaCmd()).Should(Equal(JavaCmd))
 
 				process := processes[0]
 				Expect(process.GetJvmOptions()).Should(And(
@@ -63,6 +71,28 @@ var _ = Describe("Linux java process", func() {
 			})
 		})
 
+		When("the status of a process has no uid", func() {
+			It("should skip the process and keep the others", func() {
+				m.EXPECT().RunCmd(LinuxProcessScanCmd).Return(strings.Join([]string{SpringBoot2xProcess, ExecutableProcess}, "\n"), nil)
+				m.EXPECT().RunCmd(GetProcStatusCmd(SpringBoot2xProcessId)).Return("Name:\tjava\n", nil)
+				m.EXPECT().RunCmd(GetProcStatusCmd(ExecutableProcessId)).Return(ProcStatus, nil)
+				processes, err := executor.ProcessScan()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(processes).Should(HaveLen(1))
+				Expect(processes[0].GetProcessId()).Should(Equal(ExecutableProcessId))
+			})
+		})
+
+		When("got the output of a single process", func() {
+			It("should return the process", func() {
+				m.EXPECT().RunCmd(LinuxProcessScanCmd).Return(ExecutableProcess, nil)
+				m.EXPECT().RunCmd(GetProcStatusCmd(ExecutableProcessId)).Return(ProcStatus, nil)
+				processes, err := executor.ProcessScan()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(processes).Should(HaveLen(1))
+			})
+		})
+
 		When("got empty output", func() {
 			It("should return empty process list", func() {
 				m.EXPECT().RunCmd(LinuxProcessScanCmd).Return("", nil)
@@ -100,7 +130,7 @@ var _ = Describe("Linux java process", func() {
 		})
 		When("got invalid output", func() {
 			It("should return error", func() {
-				m.EXPECT().RunCmd(LinuxGetTotalMemoryCmd).Return("  abcdefg\n", nil)
+				m.EXPECT().RunCmd(LinuxGetTotalMemoryCmd).Return("MemTotal: abcdefg kB\n", nil)
 				Expect(executor.GetTotalMemory()).Error().Should(HaveOccurred())
 			})
 		})
@@ -135,6 +165,7 @@ var _ = Describe("Linux java process", func() {
 				fileInfo, _ = os.Stat(jar)
 
 				process = &javaProcess{
+					environments: []string{},
 					options: []string{
 						"-Dspring.application.name=test",
 					},
@@ -142,7 +173,7 @@ var _ = Describe("Linux java process", func() {
 			})
 			It("should be parsed as expected", func() {
 				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxSha256Cmd, jar)).Return(checksum, nil)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil)
 				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(actual.GetAppType()).Should(Equal(SpringBootFatJar))
@@ -169,6 +200,7 @@ var _ = Describe("Linux java process", func() {
 				}
 				fileInfo, _ = os.Stat(jar)
 				process = &javaProcess{
+					environments: []string{},
 					options: []string{
 						"-Dspring.application.name=test",
 						"-Dserver.port=8085",
@@ -177,7 +209,7 @@ var _ = Describe("Linux java process", func() {
 			})
 			It("should be parsed as expected", func() {
 				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxSha256Cmd, jar)).Return("", nil)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return("", nil)
 				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(actual.GetChecksum()).Should(Not(BeEmpty()))
@@ -213,6 +245,7 @@ var _ = Describe("Linux java process", func() {
 				fileInfo, _ = os.Stat(jar)
 
 				process = &javaProcess{
+					environments: []string{},
 					options: []string{
 						"-Dspring.application.name=executable_app",
 						"--server.port=8075",
@@ -221,7 +254,7 @@ var _ = Describe("Linux java process", func() {
 			})
 			It("should be parsed as expected", func() {
 				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
-				m.EXPECT().RunCmd(fmt.Sprintf(LinuxSha256Cmd, jar)).Return(checksum, nil)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil)
 				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(actual.GetAppType()).Should(Equal(ExecutableJar))
@@ -239,19 +272,259 @@ var _ = Describe("Linux java process", func() {
 		})
 	})
 
+	Context("Parse jarfile with jar cache", func() {
+		var (
+			b        []byte
+			fileInfo os.FileInfo
+			checksum string
+			cache    JarCache
+		)
+		jar := filepath.Join("..", "mock", ExecutableJarFile)
+
+		BeforeEach(func() {
+			var err error
+			b, err = os.ReadFile(jar)
+			if err != nil {
+				panic(err)
+			}
+			fileInfo, _ = os.Stat(jar)
+			checksum = strings.Repeat("ab", 32)
+			cache, err = NewFileJarCache(GinkgoT().TempDir())
+			Expect(err).ShouldNot(HaveOccurred())
+			executor = NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, WithJarCache(cache))
+		})
+
+		When("jar file is not in cache", func() {
+			It("should be read and cached", func() {
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil)
+				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
+				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(actual.GetChecksum()).Should(Equal(checksum))
+
+				facts, ok := cache.Get(checksum)
+				Expect(ok).Should(BeTrue())
+				Expect(facts.Manifests).Should(HaveKey(MainClassField))
+			})
+		})
+
+		When("jar file is in cache", func() {
+			It("should not be read again", func() {
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil).Times(2)
+				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil).Times(1)
+				m.EXPECT().RunCmd(GetStatCmd(jar)).Return("1024 1675589080\n", nil)
+				expected, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(actual.GetChecksum()).Should(Equal(checksum))
+				Expect(actual.GetSize()).Should(Equal(int64(1024)))
+				Expect(actual.GetLastModifiedTime()).Should(Equal(time.Unix(1675589080, 0)))
+				Expect(actual.GetAppType()).Should(Equal(expected.GetAppType()))
+				jdkVersion, _ := expected.GetBuildJdkVersion()
+				Expect(actual.GetBuildJdkVersion()).Should(Equal(jdkVersion))
+				dependencies, _ := expected.GetDependencies()
+				Expect(actual.GetDependencies()).Should(Equal(dependencies))
+			})
+		})
+
+		When("checksum is not a sha256", func() {
+			It("should not be cached", func() {
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return("../../etc/passwd  "+jar, nil)
+				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
+				_, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+				_, ok := cache.Get("../../etc/passwd")
+				Expect(ok).Should(BeFalse())
+			})
+		})
+	})
+
+	Context("Parse jarfile by unzip", func() {
+		var (
+			b        []byte
+			fileInfo os.FileInfo
+			checksum string
+		)
+		jar := filepath.Join("..", "mock", ExecutableJarFile)
+		manifest := "Manifest-Version: 1.0\nMain-Class: org.springframework.boot.loader.PropertiesLauncher\nBuild-Jdk-Spec: 17\n"
+
+		BeforeEach(func() {
+			var err error
+			b, err = os.ReadFile(jar)
+			if err != nil {
+				panic(err)
+			}
+			fileInfo, _ = os.Stat(jar)
+			checksum = strings.Repeat("cd", 32)
+		})
+
+		When("unzip strategy is used", func() {
+			It("should read only the entries walked", func() {
+				executor = NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, WithJarReadStrategy(JarReadUnzip))
+				m.EXPECT().RunCmd(GetUnzipListCmd(jar)).Return("META-INF/\nMETA-INF/MANIFEST.MF\nBOOT-INF/lib/spring-boot-2.4.13.jar\nBOOT-INF/classes/application.yml\n", nil)
+				m.EXPECT().RunCmd(GetStatCmd(jar)).Return("2048 1675589080", nil)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil)
+				m.EXPECT().RunCmd(GetUnzipPipeCmd(jar, "META-INF/MANIFEST.MF")).Return(manifest, nil)
+				m.EXPECT().RunCmd(GetUnzipPipeCmd(jar, "BOOT-INF/classes/application.yml")).Return("server:\n  port: 8090\n", nil)
+
+				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(actual.GetChecksum()).Should(Equal(checksum))
+				Expect(actual.GetSize()).Should(Equal(int64(2048)))
+				Expect(actual.GetAppType()).Should(Equal(SpringBootFatJar))
+				Expect(actual.GetBuildJdkVersion()).Should(MatchVersion("17"))
+				Expect(actual.GetDependencies()).Should(ContainElement("spring-boot-2.4.13.jar"))
+				Expect(actual.GetApplicationConfigurations()).Should(HaveKeyWithValue("application.yml", "server:\n  port: 8090\n"))
+			})
+		})
+
+		When("auto strategy is used without unzip on server", func() {
+			It("should read over sftp", func() {
+				executor = NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, WithJarReadStrategy(JarReadAuto))
+				m.EXPECT().RunCmd(GetUnzipCheckCmd()).Return("", fmt.Errorf("exit status 1")).Times(1)
+				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil).Times(2)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil).Times(2)
+				for i := 0; i < 2; i++ {
+					actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(actual.GetAppType()).Should(Equal(ExecutableJar))
+				}
+			})
+		})
+
+		When("unzip fails with auto strategy", func() {
+			It("should fall back to sftp", func() {
+				executor = NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, WithJarReadStrategy(JarReadAuto))
+				m.EXPECT().RunCmd(GetUnzipCheckCmd()).Return("/usr/bin/unzip", nil)
+				m.EXPECT().RunCmd(GetUnzipListCmd(jar)).Return("", fmt.Errorf("exit status 9"))
+				m.EXPECT().Read(gomock.Any()).Return(bytes.NewReader(b), fileInfo, nil)
+				m.EXPECT().RunCmd(GetSha256Cmd(jar)).Return(checksum, nil)
+				actual, err := executor.ReadJarFile(jar, DefaultJarFileWalkers...)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(actual.GetChecksum()).Should(Equal(checksum))
+			})
+		})
+	})
+
+	Context("Range reads of the jar", func() {
+		It("should read the zip with the large blocks", func() {
+			b, err := os.ReadFile(filepath.Join("..", "mock", ExecutableJarFile))
+			Expect(err).ShouldNot(HaveOccurred())
+			counting := &countingReaderAt{r: bytes.NewReader(b)}
+			reader, err := zip.NewReader(newRangeReaderAt(counting, int64(len(b)), nil), int64(len(b)))
+			Expect(err).ShouldNot(HaveOccurred())
+			for _, f := range reader.File {
+				_, err = readFileInArchive(f)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+			Expect(counting.reads).Should(Equal((len(b) + rangeReadBlockSize - 1) / rangeReadBlockSize))
+		})
+
+		It("should keep the bandwidth under the limit after the burst", func() {
+			limiter := newRateLimiter(1024*1024, 100*1024)
+			start := time.Now()
+			limiter.wait(100 * 1024)
+			Expect(time.Since(start)).Should(BeNumerically("<", 50*time.Millisecond))
+			limiter.wait(100 * 1024)
+			limiter.wait(100 * 1024)
+			Expect(time.Since(start)).Should(BeNumerically(">=", 190*time.Millisecond))
+		})
+
+		It("should charge the range reads before reading, in the chunks of the burst", func() {
+			b := make([]byte, 300*1024)
+			recording := &recordingReaderAt{r: bytes.NewReader(b)}
+			reader := newRangeReaderAt(recording, int64(len(b)), newRateLimiter(1024*1024, 100*1024))
+			start := time.Now()
+			n, err := reader.ReadAt(make([]byte, len(b)), 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n).Should(Equal(len(b)))
+			Expect(recording.sizes).Should(Equal([]int{100 * 1024, 100 * 1024, 100 * 1024}))
+			Expect(recording.times[1].Sub(start)).Should(BeNumerically(">=", 90*time.Millisecond))
+			Expect(recording.times[2].Sub(start)).Should(BeNumerically(">=", 190*time.Millisecond))
+		})
+
+		It("should cap the burst at a block of the range reads", func() {
+			Expect(newRateLimiter(1024*1024*1024, 0).burst).Should(Equal(int64(rangeReadBlockSize)))
+		})
+
+		It("should not hold the lock while waiting", func() {
+			limiter := newRateLimiter(1024*1024, 100*1024)
+			limiter.wait(100 * 1024)
+			go limiter.wait(1024 * 1024)
+			time.Sleep(10 * time.Millisecond)
+			locked := make(chan struct{})
+			go func() {
+				limiter.mutex.Lock()
+				limiter.mutex.Unlock()
+				close(locked)
+			}()
+			Eventually(locked).WithTimeout(100 * time.Millisecond).Should(BeClosed())
+		})
+
+		It("should not save up more than the burst when idle", func() {
+			limiter := newRateLimiter(1024*1024, 100*1024)
+			limiter.wait(100 * 1024)
+			time.Sleep(300 * time.Millisecond)
+			start := time.Now()
+			limiter.wait(300 * 1024)
+			Expect(time.Since(start)).Should(BeNumerically(">=", 190*time.Millisecond))
+		})
+
+		It("should not limit without the bandwidth", func() {
+			Expect(newRateLimiter(0, 0)).Should(BeNil())
+			start := time.Now()
+			newRateLimiter(0, 0).wait(100 * 1024 * 1024)
+			Expect(time.Since(start)).Should(BeNumerically("<", 50*time.Millisecond))
+		})
+
+		It("should limit every server connection on its own", func() {
+			opt := WithBandwidthLimit(1024 * 1024)
+			first := NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, opt).(*linuxServerDiscovery)
+			second := NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg, opt).(*linuxServerDiscovery)
+			Expect(first.limiter).ShouldNot(BeNil())
+			Expect(first.limiter).ShouldNot(BeIdenticalTo(second.limiter))
+
+			start := time.Now()
+			first.limiter.wait(1024 * 1024)
+			second.limiter.wait(1024 * 1024)
+			Expect(time.Since(start)).Should(BeNumerically("<", 100*time.Millisecond))
+		})
+
+		It("should quote the entry for unzip", func() {
+			Expect(GetUnzipPipeCmd("/app/my app.jar", "BOOT-INF/classes/it's[1]*.yml")).
+				Should(Equal(`unzip -p '/app/my app.jar' 'BOOT-INF/classes/it'\''s\[1\]\*.yml'`))
+		})
+	})
+
 	Context("Get OS name", func() {
 		It("should return as expected", func() {
-			m.EXPECT().RunCmd(GetOsName()).Return("expected_os_name", nil)
+			m.EXPECT().RunCmd(GetOsReleaseCmd()).Return(OsRelease, nil)
+
+			Expect(executor.GetOsName()).Should(Equal("ubuntu"))
+		})
+
+		It("should fall back to centos release", func() {
+			m.EXPECT().RunCmd(GetOsReleaseCmd()).Return("", fmt.Errorf("cat: /etc/os-release: No such file or directory"))
+			m.EXPECT().RunCmd(GetCentOsReleaseCmd()).Return("CentOS release 6.10 (Final)\n", nil)
 
-			Expect(executor.GetOsName()).Should(Equal("expected_os_name"))
+			Expect(executor.GetOsName()).Should(Equal("CentOS"))
 		})
 	})
 
 	Context("Get OS version", func() {
 		It("should return as expected", func() {
-			m.EXPECT().RunCmd(GetOsName()).Return("expected_os_version", nil)
+			m.EXPECT().RunCmd(GetOsReleaseCmd()).Return(OsRelease, nil)
 
-			Expect(executor.GetOsName()).Should(Equal("expected_os_version"))
+			Expect(executor.GetOsVersion()).Should(Equal("18.04"))
+		})
+
+		It("should fall back to centos release", func() {
+			m.EXPECT().RunCmd(GetOsReleaseCmd()).Return("NAME=\"CentOS Linux\"\n", nil)
+			m.EXPECT().RunCmd(GetCentOsReleaseCmd()).Return("CentOS Linux release 7.9.2009 (Core)\n", nil)
+
+			Expect(executor.GetOsVersion()).Should(Equal("7.9.2009"))
 		})
 	})
 
@@ -261,6 +534,7 @@ var _ = Describe("Linux java process", func() {
 		)
 		BeforeEach(func() {
 			executor = NewLinuxServerDiscovery(ctx, m, credentialProvider, cfg)
+			m.EXPECT().Escalate(gomock.Any()).AnyTimes()
 
 			for i := 0; i < 10; i++ {
 				username := fmt.Sprintf("username_%d", i)
@@ -435,3 +709,26 @@ func (m matchDurationAround) NegatedFailureMessage(actual interface{}) (message
 	}
 	return fmt.Sprintf("unknown duration, %v", actual)
 }
+
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+	return c.r.ReadAt(p, off)
+}
+
+// recordingReaderAt records the size and the time of every read
+type recordingReaderAt struct {
+	r     io.ReaderAt
+	sizes []int
+	times []time.Time
+}
+
+func (c *recordingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.sizes = append(c.sizes, len(p))
+	c.times = append(c.times, time.Now())
+	return c.r.ReadAt(p, off)
+}
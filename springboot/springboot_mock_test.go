@@ -58,6 +58,77 @@ func (mr *MockDiscoveryExecutorMockRecorder) Discover(ctx, server interface{}, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discover", reflect.TypeOf((*MockDiscoveryExecutor)(nil).Discover), varargs...)
 }
 
+// DiscoverStream mocks base method.
+func (m *MockDiscoveryExecutor) DiscoverStream(ctx context.Context, callback DiscoveryCallback, server ServerConnectionInfo, alternativeConnectionInfos ...ServerConnectionInfo) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, callback, server}
+	for _, a := range alternativeConnectionInfos {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DiscoverStream", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscoverStream indicates an expected call of DiscoverStream.
+func (mr *MockDiscoveryExecutorMockRecorder) DiscoverStream(ctx, callback, server interface{}, alternativeConnectionInfos ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, callback, server}, alternativeConnectionInfos...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverStream", reflect.TypeOf((*MockDiscoveryExecutor)(nil).DiscoverStream), varargs...)
+}
+
+// MockJarCache is a mock of JarCache interface.
+type MockJarCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockJarCacheMockRecorder
+}
+
+// MockJarCacheMockRecorder is the mock recorder for MockJarCache.
+type MockJarCacheMockRecorder struct {
+	mock *MockJarCache
+}
+
+// NewMockJarCache creates a new mock instance.
+func NewMockJarCache(ctrl *gomock.Controller) *MockJarCache {
+	mock := &MockJarCache{ctrl: ctrl}
+	mock.recorder = &MockJarCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJarCache) EXPECT() *MockJarCacheMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockJarCache) Get(checksum string) (*JarFacts, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", checksum)
+	ret0, _ := ret[0].(*JarFacts)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockJarCacheMockRecorder) Get(checksum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockJarCache)(nil).Get), checksum)
+}
+
+// Put mocks base method.
+func (m *MockJarCache) Put(checksum string, facts *JarFacts) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", checksum, facts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockJarCacheMockRecorder) Put(checksum, facts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockJarCache)(nil).Put), checksum, facts)
+}
+
 // MockCredentialProvider is a mock of CredentialProvider interface.
 type MockCredentialProvider struct {
 	ctrl     *gomock.Controller
@@ -613,6 +684,21 @@ func (mr *MockJavaProcessMockRecorder) GetJavaCmd() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJavaCmd", reflect.TypeOf((*MockJavaProcess)(nil).GetJavaCmd))
 }
 
+// GetJdkRelease mocks base method.
+func (m *MockJavaProcess) GetJdkRelease() (*JdkRelease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJdkRelease")
+	ret0, _ := ret[0].(*JdkRelease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJdkRelease indicates an expected call of GetJdkRelease.
+func (mr *MockJavaProcessMockRecorder) GetJdkRelease() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJdkRelease", reflect.TypeOf((*MockJavaProcess)(nil).GetJdkRelease))
+}
+
 // GetJvmMemory mocks base method.
 func (m *MockJavaProcess) GetJvmMemory() (int64, error) {
 	m.ctrl.T.Helper()
@@ -628,6 +714,21 @@ func (mr *MockJavaProcessMockRecorder) GetJvmMemory() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJvmMemory", reflect.TypeOf((*MockJavaProcess)(nil).GetJvmMemory))
 }
 
+// GetJvmOptionSources mocks base method.
+func (m *MockJavaProcess) GetJvmOptionSources() ([]JvmOption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJvmOptionSources")
+	ret0, _ := ret[0].([]JvmOption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJvmOptionSources indicates an expected call of GetJvmOptionSources.
+func (mr *MockJavaProcessMockRecorder) GetJvmOptionSources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJvmOptionSources", reflect.TypeOf((*MockJavaProcess)(nil).GetJvmOptionSources))
+}
+
 // GetJvmOptions mocks base method.
 func (m *MockJavaProcess) GetJvmOptions() ([]string, error) {
 	m.ctrl.T.Helper()
@@ -767,6 +868,18 @@ func (mr *MockServerConnectorMockRecorder) Connect(username, password interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockServerConnector)(nil).Connect), username, password)
 }
 
+// Escalate mocks base method.
+func (m *MockServerConnector) Escalate(escalation *Escalation) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Escalate", escalation)
+}
+
+// Escalate indicates an expected call of Escalate.
+func (mr *MockServerConnectorMockRecorder) Escalate(escalation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Escalate", reflect.TypeOf((*MockServerConnector)(nil).Escalate), escalation)
+}
+
 // FQDN mocks base method.
 func (m *MockServerConnector) FQDN() string {
 	m.ctrl.T.Helper()
@@ -812,6 +925,21 @@ func (mr *MockServerConnectorMockRecorder) RunCmd(cmd interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmd", reflect.TypeOf((*MockServerConnector)(nil).RunCmd), cmd)
 }
 
+// RunPrivilegedCmd mocks base method.
+func (m *MockServerConnector) RunPrivilegedCmd(cmd string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunPrivilegedCmd", cmd)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunPrivilegedCmd indicates an expected call of RunPrivilegedCmd.
+func (mr *MockServerConnectorMockRecorder) RunPrivilegedCmd(cmd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunPrivilegedCmd", reflect.TypeOf((*MockServerConnector)(nil).RunPrivilegedCmd), cmd)
+}
+
 // Username mocks base method.
 func (m *MockServerConnector) Username() string {
 	m.ctrl.T.Helper()
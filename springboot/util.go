@@ -138,7 +138,7 @@ func (c cmdMatcher) Matches(x interface{}) bool {
 	pattern = strings.ReplaceAll(pattern, "%d", "[0-9]+")
 	pattern = strings.ReplaceAll(pattern, "%\\[1\\]d", "[0-9]+")
 	pattern = strings.ReplaceAll(pattern, "%f", "[0-9\\.]+")
-	pattern = strings.ReplaceAll(pattern, "%s", "[0-9a-zA-Z\\-_\\./]+")
+	pattern = strings.ReplaceAll(pattern, "%s", "[0-9a-zA-Z\\-_\\./']+")
 	pattern = strings.ReplaceAll(pattern, "^^d", "%d")
 	r := regexp.MustCompile(pattern)
 	find := r.FindString(s)